@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/laukkw/kwstart/errors"
-	"strings"
 )
 
 // AbiDecoder 将数据按类型解析到 []interface中
@@ -27,22 +26,29 @@ func AbiDecoder(argTypes []string, input []byte, argValues []interface{}) error
 	}
 }
 
+// AbiDecodeExprAndStringify 按参数表达式解码, 具名 tuple 字段按名称输出
 func AbiDecodeExprAndStringify(expr string, input []byte) ([]string, error) {
-	argsList := parseArgumentExpr(expr)
-	var argTypes []string
-	for _, v := range argsList {
-		argTypes = append(argTypes, v.Type)
+	args, err := buildArgumentsFromExpr(expr)
+	if err != nil {
+		return nil, err
 	}
-
-	return AbiMarshalStringValues(argTypes, input)
+	values, err := args.UnpackValues(input)
+	if err != nil {
+		return nil, err
+	}
+	return StringifyArgumentValues(args, values)
 }
 
 func AbiMarshalStringValues(argTypes []string, input []byte) ([]string, error) {
-	values, err := AbiDecoderWithReturnedValues(argTypes, input)
+	args, err := buildArgumentsFromTypes(argTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build abi: %v", err)
+	}
+	values, err := args.UnpackValues(input)
 	if err != nil {
 		return nil, err
 	}
-	return StringifyValues(values)
+	return StringifyArgumentValues(args, values)
 }
 func AbiDecoderWithReturnedValues(argTypes []string, input []byte) ([]interface{}, error) {
 	args, err := buildArgumentsFromTypes(argTypes)
@@ -52,42 +58,22 @@ func AbiDecoderWithReturnedValues(argTypes []string, input []byte) ([]interface{
 	return args.UnpackValues(input)
 }
 
-func parseArgumentExpr(expr string) []abiArgument {
-	var args []abiArgument
-	expr = strings.Trim(expr, "() ")
-	p := strings.Split(expr, ",")
-
-	if expr == "" {
-		return args
-	}
-	for _, v := range p {
-		v = strings.Trim(v, " ")
-		n := strings.Split(v, " ")
-		arg := abiArgument{Type: n[0]}
-		if len(n) > 1 {
-			arg.Name = n[1]
-		}
-		args = append(args, arg)
-	}
-	return args
-}
 func buildArgumentsFromTypes(argTypes []string) (abi.Arguments, error) {
 	args := abi.Arguments{}
 	for _, argType := range argTypes {
-		abiType, err := abi.NewType(argType, "", nil)
+		arg, err := parseAbiType(argType)
+		if err != nil {
+			return nil, err
+		}
+		abiType, err := arg.abiType()
 		if err != nil {
 			return nil, err
 		}
-		args = append(args, abi.Argument{Type: abiType})
+		args = append(args, abi.Argument{Name: arg.Name, Type: abiType, Indexed: arg.Indexed})
 	}
 	return args, nil
 }
 
-type abiArgument struct {
-	Name string `json:"name,omitempty"`
-	Type string `json:"type"`
-}
-
 func StringifyValues(values []interface{}) ([]string, error) {
 	strs := []string{}
 
@@ -115,3 +101,23 @@ func StringifyValues(values []interface{}) ([]string, error) {
 
 	return strs, nil
 }
+
+// DecodeInputData 按前 4 个字节匹配 abi 中的方法, 返回方法名和按参数名索引的值
+func DecodeInputData(contractABI abi.ABI, data []byte) (string, map[string]interface{}, error) {
+	if len(data) < 4 {
+		return "", nil, errors.New("invalid input data - shorter than method selector")
+	}
+	method, err := contractABI.MethodById(data[:4])
+	if err != nil {
+		return "", nil, err
+	}
+	values, err := method.Inputs.UnpackValues(data[4:])
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decode %s input: %w", method.Sig, err)
+	}
+	args := make(map[string]interface{}, len(values))
+	for i, arg := range method.Inputs {
+		args[abiArgName(arg, i)] = values[i]
+	}
+	return method.Name, args, nil
+}
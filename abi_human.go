@@ -0,0 +1,239 @@
+package laukit
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"strings"
+)
+
+// humanAbiEntry json abi 中的一项, 由可读签名转换而来
+type humanAbiEntry struct {
+	Type            string        `json:"type"`
+	Name            string        `json:"name,omitempty"`
+	Inputs          []abiArgument `json:"inputs"`
+	Outputs         []abiArgument `json:"outputs"`
+	StateMutability string        `json:"stateMutability,omitempty"`
+	Anonymous       bool          `json:"anonymous,omitempty"`
+}
+
+// ParseHumanABI 从可读签名构建 abi, 例如
+//
+//	function transfer(address to, uint256 amount) returns (bool)
+//	function balanceOf(address owner) view returns (uint256)
+//	event Transfer(address indexed from, address indexed to, uint256 value)
+//	error InsufficientBalance(uint256 available, uint256 required)
+//	struct Order { address maker; uint256 amount; }
+//	function fill(Order[] orders) payable
+//
+// 也支持 constructor / fallback / receive, 没有关键字的签名按 function 处理
+// struct 可以在使用它的签名之后声明
+func ParseHumanABI(signatures ...string) (abi.ABI, error) {
+	structs := make(map[string][]abiArgument)
+	var entries []humanAbiEntry
+	for _, sig := range signatures {
+		sig = strings.TrimSuffix(strings.TrimSpace(sig), ";")
+		if sig == "" || strings.HasPrefix(sig, "//") {
+			continue
+		}
+		if strings.HasPrefix(sig, "struct ") {
+			name, fields, err := parseHumanStruct(sig)
+			if err != nil {
+				return abi.ABI{}, err
+			}
+			structs[name] = fields
+			continue
+		}
+		entry, err := parseHumanSignature(sig)
+		if err != nil {
+			return abi.ABI{}, err
+		}
+		entries = append(entries, entry)
+	}
+
+	for i := range entries {
+		for _, args := range [][]abiArgument{entries[i].Inputs, entries[i].Outputs} {
+			for j := range args {
+				resolved, err := resolveHumanArgument(args[j], structs, nil)
+				if err != nil {
+					return abi.ABI{}, fmt.Errorf("%s %s: %w", entries[i].Type, entries[i].Name, err)
+				}
+				args[j] = resolved
+			}
+		}
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return abi.ABI{}, err
+	}
+	return ParseABI(string(data))
+}
+
+func MustParseHumanABI(signatures ...string) abi.ABI {
+	parsed, err := ParseHumanABI(signatures...)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// parseHumanStruct 解析 struct Name { type name; ... }
+func parseHumanStruct(sig string) (string, []abiArgument, error) {
+	open, end := strings.Index(sig, "{"), strings.LastIndex(sig, "}")
+	if open < 0 || end < open || strings.TrimSpace(sig[end+1:]) != "" {
+		return "", nil, fmt.Errorf("invalid struct declaration %q", sig)
+	}
+	name := strings.TrimSpace(strings.TrimPrefix(sig[:open], "struct "))
+	if name == "" || !isHumanIdent(name) {
+		return "", nil, fmt.Errorf("invalid struct name in %q", sig)
+	}
+	body := strings.TrimSuffix(strings.TrimSpace(sig[open+1:end]), ";")
+	fields, err := parseArgumentExpr(strings.ReplaceAll(body, ";", ","))
+	if err != nil {
+		return "", nil, fmt.Errorf("struct %s: %w", name, err)
+	}
+	for _, f := range fields {
+		if f.Name == "" {
+			return "", nil, fmt.Errorf("struct %s: field %s has no name", name, f.canonical())
+		}
+	}
+	return name, fields, nil
+}
+
+// parseHumanSignature 解析 `关键字 名字(参数) 修饰词 returns (返回值)`
+func parseHumanSignature(sig string) (humanAbiEntry, error) {
+	var entry humanAbiEntry
+	open := strings.Index(sig, "(")
+	if open < 0 {
+		return entry, fmt.Errorf("invalid signature %q: missing (", sig)
+	}
+	head := strings.Fields(sig[:open])
+	switch {
+	case len(head) == 1 && (head[0] == "constructor" || head[0] == "fallback" || head[0] == "receive"):
+		entry.Type = head[0]
+	case len(head) == 2 && (head[0] == "function" || head[0] == "event" || head[0] == "error"):
+		entry.Type, entry.Name = head[0], head[1]
+	case len(head) == 1:
+		entry.Type, entry.Name = "function", head[0]
+	default:
+		return entry, fmt.Errorf("invalid signature %q", sig)
+	}
+	if entry.Name != "" && !isHumanIdent(entry.Name) {
+		return entry, fmt.Errorf("invalid name %q in signature %q", entry.Name, sig)
+	}
+
+	end := matchingParen(sig, open)
+	if end < 0 {
+		return entry, fmt.Errorf("invalid signature %q: unbalanced parentheses", sig)
+	}
+	inputs, err := parseArgumentExpr(sig[open+1 : end])
+	if err != nil {
+		return entry, err
+	}
+	entry.Inputs = append([]abiArgument{}, inputs...)
+	if entry.Type == "function" {
+		entry.Outputs = []abiArgument{}
+	}
+
+	tail := strings.TrimSpace(sig[end+1:])
+	if i := strings.Index(tail, "returns"); i >= 0 {
+		if entry.Type != "function" {
+			return entry, fmt.Errorf("invalid signature %q: only functions have returns", sig)
+		}
+		outputs, err := parseArgumentExpr(strings.TrimSpace(tail[i+len("returns"):]))
+		if err != nil {
+			return entry, err
+		}
+		entry.Outputs = append([]abiArgument{}, outputs...)
+		tail = tail[:i]
+	}
+
+	for _, word := range strings.Fields(tail) {
+		switch word {
+		case "view", "pure", "payable", "nonpayable":
+			if entry.Type == "event" || entry.Type == "error" {
+				return entry, fmt.Errorf("invalid signature %q: unexpected %s", sig, word)
+			}
+			entry.StateMutability = word
+		case "anonymous":
+			if entry.Type != "event" {
+				return entry, fmt.Errorf("invalid signature %q: only events can be anonymous", sig)
+			}
+			entry.Anonymous = true
+		case "external", "public", "virtual", "override":
+		default:
+			return entry, fmt.Errorf("invalid signature %q: unexpected %s", sig, word)
+		}
+	}
+	switch {
+	case entry.Type == "receive":
+		entry.StateMutability = "payable"
+	case entry.StateMutability == "" && entry.Type != "event" && entry.Type != "error":
+		entry.StateMutability = "nonpayable"
+	}
+	return entry, nil
+}
+
+// resolveHumanArgument 把 struct 名字替换为 tuple, 并给没有名字的 tuple 成员按位置命名
+func resolveHumanArgument(arg abiArgument, structs map[string][]abiArgument, seen []string) (abiArgument, error) {
+	base := arg.Type
+	suffix := ""
+	if i := strings.Index(base, "["); i >= 0 {
+		base, suffix = base[:i], base[i:]
+	}
+	if fields, ok := structs[base]; ok {
+		for _, s := range seen {
+			if s == base {
+				return arg, fmt.Errorf("recursive struct %s", base)
+			}
+		}
+		seen = append(seen, base)
+		arg.Type = "tuple" + suffix
+		arg.InternalType = "struct " + base + suffix
+		arg.Components = fields
+	}
+	if len(arg.Components) == 0 {
+		return arg, nil
+	}
+	components := make([]abiArgument, len(arg.Components))
+	for i, c := range arg.Components {
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("field%d", i)
+		}
+		resolved, err := resolveHumanArgument(c, structs, seen)
+		if err != nil {
+			return arg, err
+		}
+		components[i] = resolved
+	}
+	arg.Components = components
+	return arg, nil
+}
+
+func isHumanIdent(name string) bool {
+	for i, c := range name {
+		if c == '_' || c == '$' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return name != ""
+}
+
+// matchingParen 返回与 s[open] 处的 ( 匹配的 ) 的位置
+func matchingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
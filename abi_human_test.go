@@ -0,0 +1,83 @@
+package laukit
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// erc20HumanABI 与 erc20JSONABI 描述同一组 error / event / function
+var erc20HumanABI = []string{
+	"error InsufficientBalance()",
+	"event Transfer(address indexed from, address indexed to, uint256 amount)",
+	"function balanceOf(address owner) view returns (uint256 result)",
+	"function transfer(address to, uint256 amount) returns (bool)",
+}
+
+const erc20JSONABI = `[
+	{"inputs": [], "name": "InsufficientBalance", "type": "error"},
+	{"anonymous": false, "inputs": [
+		{"indexed": true, "internalType": "address", "name": "from", "type": "address"},
+		{"indexed": true, "internalType": "address", "name": "to", "type": "address"},
+		{"indexed": false, "internalType": "uint256", "name": "amount", "type": "uint256"}
+	], "name": "Transfer", "type": "event"},
+	{"inputs": [{"internalType": "address", "name": "owner", "type": "address"}], "name": "balanceOf",
+		"outputs": [{"internalType": "uint256", "name": "result", "type": "uint256"}], "stateMutability": "view", "type": "function"},
+	{"inputs": [{"internalType": "address", "name": "to", "type": "address"}, {"internalType": "uint256", "name": "amount", "type": "uint256"}],
+		"name": "transfer", "outputs": [{"internalType": "bool", "name": "", "type": "bool"}], "stateMutability": "nonpayable", "type": "function"}
+]`
+
+func TestParseHumanABI(t *testing.T) {
+	t.Run("erc20", func(t *testing.T) {
+		human := MustParseHumanABI(erc20HumanABI...)
+		expected := MustParseABI(erc20JSONABI)
+		if !reflect.DeepEqual(human.Methods, expected.Methods) || !reflect.DeepEqual(human.Events, expected.Events) || !reflect.DeepEqual(human.Errors, expected.Errors) {
+			t.Fatal("human readable abi does not match json abi")
+		}
+		a, _ := EncodeInputData(human, "transfer", common.HexToAddress("0x00"), big.NewInt(1))
+		b, _ := EncodeInputData(expected, "transfer", common.HexToAddress("0x00"), big.NewInt(1))
+		if len(a) == 0 || !reflect.DeepEqual(a, b) {
+			t.Fatalf("unexpected input data %x %x", a, b)
+		}
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		parsed, err := ParseHumanABI(
+			"function fill(Order[] orders, (uint8 v, bytes32 r, bytes32 s) sig) payable returns (uint256 filled)",
+			"struct Order { Asset asset; address maker; uint256 amount; }",
+			"struct Asset { address token; uint256 id }",
+			"constructor(address owner)",
+			"receive() external payable",
+			"event Log(string indexed message) anonymous",
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fill := parsed.Methods["fill"]
+		if fill.Sig != "fill(((address,uint256),address,uint256)[],(uint8,bytes32,bytes32))" || !fill.IsPayable() {
+			t.Fatalf("unexpected method %s %s", fill.Sig, fill.StateMutability)
+		}
+		if fill.Inputs[0].Type.Elem.TupleRawName != "Order" {
+			t.Fatalf("unexpected tuple name %q", fill.Inputs[0].Type.Elem.TupleRawName)
+		}
+		if len(parsed.Constructor.Inputs) != 1 || !parsed.HasReceive() || !parsed.Events["Log"].Anonymous {
+			t.Fatalf("unexpected abi %+v", parsed)
+		}
+	})
+
+	for _, sig := range []string{
+		"function transfer(address to",
+		"function transfer(address to) indexed",
+		"event Transfer(address from) returns (bool)",
+		"function fill(Order order)",
+		"struct A { B b; }\nstruct B { A a; }",
+	} {
+		if _, err := ParseHumanABI(sig); err == nil {
+			t.Fatalf("%q: expected error", sig)
+		}
+	}
+	if _, err := ParseHumanABI("struct A { B b; }", "struct B { A a; }", "function f(A a)"); err == nil {
+		t.Fatal("expected recursive struct error")
+	}
+}
@@ -0,0 +1,351 @@
+package laukit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ParseArguments 把参数列表表达式转换为 abi.Arguments, 例如 "address to, (address,uint256)[] items"
+func ParseArguments(expr string) (abi.Arguments, error) {
+	return buildArgumentsFromExpr(expr)
+}
+
+// AbiMarshalJSON 按类型把值格式化为 JSON 数组
+//   - address 为校验和格式的 hex
+//   - bytes / bytesN 为 0x 开头的 hex
+//   - int / uint 为十进制字符串, 避免 JSON number 丢失精度
+//   - tuple 为按成员名索引的对象, 成员顺序与类型定义一致
+func AbiMarshalJSON(args abi.Arguments, values []interface{}) ([]byte, error) {
+	if len(args) != len(values) {
+		return nil, fmt.Errorf("invalid arguments - expected %d values, got %d", len(args), len(values))
+	}
+	out := make([]interface{}, len(args))
+	for i, arg := range args {
+		v, err := abiJSONValue(arg.Type, reflect.ValueOf(values[i]))
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %w", abiArgName(arg, i), err)
+		}
+		out[i] = v
+	}
+	return json.Marshal(out)
+}
+
+// AbiParseJSON 是 AbiMarshalJSON 的逆过程, 把 JSON 数组转换为可以直接交给 AbiCoder / args.Pack 的值
+// 整数可以是十进制或 0x 开头的字符串, 也可以是 JSON number, tuple 可以是对象或按顺序的数组
+func AbiParseJSON(args abi.Arguments, data []byte) ([]interface{}, error) {
+	var raw []interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid json arguments: %w", err)
+	}
+	if len(raw) != len(args) {
+		return nil, fmt.Errorf("invalid arguments - expected %d values, got %d", len(args), len(raw))
+	}
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		v, err := abiValueFromJSON(arg.Type, raw[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %w", abiArgName(arg, i), err)
+		}
+		values[i] = v.Interface()
+	}
+	return values, nil
+}
+
+// StringifyArgumentValues 按类型把值转换为字符串, 基本类型与 AbiMarshalJSON 中的格式一致, 数组和 tuple 为 JSON
+func StringifyArgumentValues(args abi.Arguments, values []interface{}) ([]string, error) {
+	if len(args) != len(values) {
+		return nil, fmt.Errorf("invalid arguments - expected %d values, got %d", len(args), len(values))
+	}
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		v, err := abiJSONValue(arg.Type, reflect.ValueOf(values[i]))
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %w", abiArgName(arg, i), err)
+		}
+		switch s := v.(type) {
+		case string:
+			strs[i] = s
+		case bool:
+			strs[i] = strconv.FormatBool(s)
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			strs[i] = string(b)
+		}
+	}
+	return strs, nil
+}
+
+// abiJSONObject 保持成员顺序的 JSON 对象
+type abiJSONObject struct {
+	keys   []string
+	values []interface{}
+}
+
+func (o abiJSONObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func abiJSONValue(t abi.Type, v reflect.Value) (interface{}, error) {
+	for v.IsValid() && (v.Kind() == reflect.Interface || (v.Kind() == reflect.Ptr && v.Type() != reflect.TypeOf(&big.Int{}))) {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, fmt.Errorf("missing value for %s", t.String())
+	}
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return strconv.FormatInt(v.Int(), 10), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return strconv.FormatUint(v.Uint(), 10), nil
+		}
+		if n, ok := v.Interface().(*big.Int); ok && n != nil {
+			return n.String(), nil
+		}
+	case abi.BoolTy:
+		if v.Kind() == reflect.Bool {
+			return v.Bool(), nil
+		}
+	case abi.StringTy:
+		if v.Kind() == reflect.String {
+			return v.String(), nil
+		}
+	case abi.AddressTy:
+		if addr, ok := v.Interface().(common.Address); ok {
+			return addr.Hex(), nil
+		}
+	case abi.BytesTy, abi.FixedBytesTy, abi.FunctionTy, abi.HashTy:
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			b := make([]byte, v.Len())
+			for i := range b {
+				b[i] = byte(v.Index(i).Uint())
+			}
+			return hexutil.Encode(b), nil
+		}
+	case abi.SliceTy, abi.ArrayTy:
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			out := make([]interface{}, v.Len())
+			for i := range out {
+				elem, err := abiJSONValue(*t.Elem, v.Index(i))
+				if err != nil {
+					return nil, err
+				}
+				out[i] = elem
+			}
+			return out, nil
+		}
+	case abi.TupleTy:
+		if v.Kind() == reflect.Struct && v.NumField() == len(t.TupleElems) {
+			obj := abiJSONObject{keys: t.TupleRawNames, values: make([]interface{}, len(t.TupleElems))}
+			for i, elem := range t.TupleElems {
+				value, err := abiJSONValue(*elem, v.Field(i))
+				if err != nil {
+					return nil, err
+				}
+				obj.values[i] = value
+			}
+			return obj, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot format %v as %s", v.Type(), t.String())
+}
+
+func abiValueFromJSON(t abi.Type, in interface{}) (reflect.Value, error) {
+	target := t.GetType()
+	out := reflect.New(target).Elem()
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, err := parseJSONInteger(in)
+		if err != nil {
+			return out, err
+		}
+		if err := checkIntegerRange(t, n); err != nil {
+			return out, err
+		}
+		switch target.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			out.SetInt(n.Int64())
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			out.SetUint(n.Uint64())
+		default:
+			out.Set(reflect.ValueOf(n))
+		}
+		return out, nil
+	case abi.BoolTy:
+		switch b := in.(type) {
+		case bool:
+			out.SetBool(b)
+			return out, nil
+		case string:
+			parsed, err := strconv.ParseBool(b)
+			if err != nil {
+				return out, fmt.Errorf("invalid bool %q", b)
+			}
+			out.SetBool(parsed)
+			return out, nil
+		}
+	case abi.StringTy:
+		if s, ok := in.(string); ok {
+			out.SetString(s)
+			return out, nil
+		}
+	case abi.AddressTy:
+		if s, ok := in.(string); ok {
+			if !common.IsHexAddress(s) {
+				return out, fmt.Errorf("invalid address %q", s)
+			}
+			out.Set(reflect.ValueOf(common.HexToAddress(s)))
+			return out, nil
+		}
+	case abi.BytesTy, abi.FixedBytesTy, abi.FunctionTy:
+		s, ok := in.(string)
+		if !ok {
+			break
+		}
+		if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+			s = "0x" + s
+		}
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return out, fmt.Errorf("invalid hex %q: %w", in, err)
+		}
+		if t.T == abi.BytesTy {
+			out.SetBytes(b)
+			return out, nil
+		}
+		if len(b) != target.Len() {
+			return out, fmt.Errorf("invalid %s: expected %d bytes, got %d", t.String(), target.Len(), len(b))
+		}
+		reflect.Copy(out, reflect.ValueOf(b))
+		return out, nil
+	case abi.SliceTy, abi.ArrayTy:
+		list, ok := in.([]interface{})
+		if !ok {
+			break
+		}
+		if t.T == abi.ArrayTy && len(list) != t.Size {
+			return out, fmt.Errorf("invalid %s: expected %d elements, got %d", t.String(), t.Size, len(list))
+		}
+		if t.T == abi.SliceTy {
+			out = reflect.MakeSlice(target, len(list), len(list))
+		}
+		for i, item := range list {
+			elem, err := abiValueFromJSON(*t.Elem, item)
+			if err != nil {
+				return out, fmt.Errorf("element %d: %w", i, err)
+			}
+			out.Index(i).Set(elem)
+		}
+		return out, nil
+	case abi.TupleTy:
+		var items []interface{}
+		switch tuple := in.(type) {
+		case []interface{}:
+			items = tuple
+		case map[string]interface{}:
+			for i, name := range t.TupleRawNames {
+				item, ok := tuple[name]
+				if !ok {
+					item, ok = tuple[target.Field(i).Name]
+				}
+				if !ok {
+					return out, fmt.Errorf("missing tuple field %s", name)
+				}
+				items = append(items, item)
+			}
+		default:
+			return out, fmt.Errorf("invalid %s: expected object or array, got %T", t.String(), in)
+		}
+		if len(items) != len(t.TupleElems) {
+			return out, fmt.Errorf("invalid %s: expected %d fields, got %d", t.String(), len(t.TupleElems), len(items))
+		}
+		for i, elem := range t.TupleElems {
+			field, err := abiValueFromJSON(*elem, items[i])
+			if err != nil {
+				return out, fmt.Errorf("field %s: %w", t.TupleRawNames[i], err)
+			}
+			out.Field(i).Set(field)
+		}
+		return out, nil
+	}
+	return out, fmt.Errorf("cannot parse %T as %s", in, t.String())
+}
+
+func parseJSONInteger(in interface{}) (*big.Int, error) {
+	var s string
+	switch v := in.(type) {
+	case json.Number:
+		s = v.String()
+		// 1e3 这类指数形式只要是整数就接受, 1.5 / 1e-3 返回错误
+		if strings.ContainsAny(s, ".eE") {
+			// 256 位整数用不到更大的指数, 避免 1e1000000000 这类输入计算巨大的 10 的幂
+			if i := strings.IndexAny(s, "eE"); i >= 0 {
+				if exp, err := strconv.Atoi(s[i+1:]); err != nil || exp > 100 || exp < -100 {
+					return nil, fmt.Errorf("invalid integer %q: exponent out of range", s)
+				}
+			}
+			r, ok := new(big.Rat).SetString(s)
+			if !ok || !r.IsInt() {
+				return nil, fmt.Errorf("invalid integer %q: not an integer", s)
+			}
+			return r.Num(), nil
+		}
+	case string:
+		s = strings.TrimSpace(v)
+	default:
+		return nil, fmt.Errorf("invalid integer %v", in)
+	}
+	base := 10
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") || strings.HasPrefix(s, "-0x") {
+		s, base = strings.Replace(strings.Replace(s, "0x", "", 1), "0X", "", 1), 16
+	}
+	n, ok := new(big.Int).SetString(s, base)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", s)
+	}
+	return n, nil
+}
+
+func checkIntegerRange(t abi.Type, n *big.Int) error {
+	if t.T == abi.UintTy {
+		if n.Sign() < 0 || n.BitLen() > t.Size {
+			return fmt.Errorf("%s out of range for %s", n, t.String())
+		}
+		return nil
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1))
+	if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return fmt.Errorf("%s out of range for %s", n, t.String())
+	}
+	return nil
+}
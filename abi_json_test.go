@@ -0,0 +1,118 @@
+package laukit
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestAbiMarshalJSON(t *testing.T) {
+	args, err := ParseArguments("address owner, bytes32 salt, uint8 v, int256 delta, bytes data, address[] spenders, (address token, uint256 amount)[] items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := common.HexToAddress("0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17")
+	type item struct {
+		Token  common.Address
+		Amount *big.Int
+	}
+	values := []interface{}{
+		owner,
+		[32]byte{1},
+		uint8(27),
+		big.NewInt(-5),
+		[]byte{0xde, 0xad},
+		[]common.Address{owner},
+		[]item{{owner, new(big.Int).Exp(big.NewInt(10), big.NewInt(30), nil)}},
+	}
+	encoded, err := args.Pack(values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unpacked, err := args.UnpackValues(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := AbiMarshalJSON(args, unpacked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `["` + owner.Hex() + `",` +
+		`"0x0100000000000000000000000000000000000000000000000000000000000000",` +
+		`"27","-5","0xdead",["` + owner.Hex() + `"],` +
+		`[{"token":"` + owner.Hex() + `","amount":"1000000000000000000000000000000"}]]`
+	if string(data) != expected {
+		t.Fatalf("unexpected json %s", data)
+	}
+
+	parsed, err := AbiParseJSON(args, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repacked, err := args.Pack(parsed...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(repacked, encoded) {
+		t.Fatalf("round trip mismatch %x", repacked)
+	}
+
+	strs, err := StringifyArgumentValues(args, unpacked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strs[0] != owner.Hex() || strs[2] != "27" || strs[4] != "0xdead" || strs[5] != `["`+owner.Hex()+`"]` {
+		t.Fatalf("unexpected strings %v", strs)
+	}
+}
+
+func TestAbiParseJSON(t *testing.T) {
+	args, _ := ParseArguments("uint256 amount, uint8 v, bool ok, (address to, bytes4 sel) call")
+	for _, number := range []string{"1.5", "1e-3", "1e1000000000", "1e-1000000000"} {
+		if _, err := AbiParseJSON(args, []byte(`[`+number+`, "0x1b", "true", ["0x487ee5d805b3c95eb23055dc92aad29a89961f17", "a9059cbb"]]`)); err == nil {
+			t.Fatalf("expected error for non-integer number %s", number)
+		}
+	}
+	values, err := AbiParseJSON(args, []byte(`[1000, "0x1b", "true", ["0x487ee5d805b3c95eb23055dc92aad29a89961f17", "a9059cbb"]]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[0].(*big.Int).Int64() != 1000 || values[1].(uint8) != 27 || values[2] != true {
+		t.Fatalf("unexpected values %v", values)
+	}
+	if _, err := args.Pack(values...); err != nil {
+		t.Fatal(err)
+	}
+	// 指数形式的整数
+	for _, number := range []string{"1e3", "1.0E3"} {
+		parsed, err := AbiParseJSON(args, []byte(`[`+number+`, "0x1b", "true", ["0x487ee5d805b3c95eb23055dc92aad29a89961f17", "a9059cbb"]]`))
+		if err != nil || parsed[0].(*big.Int).Int64() != 1000 {
+			t.Fatalf("%s: unexpected values %v %v", number, parsed, err)
+		}
+	}
+
+	// 只有类型时解析结果可以直接交给 AbiCoder
+	argTypes := []string{"uint256", "(address,bytes4)"}
+	typed, _ := buildArgumentsFromTypes(argTypes)
+	values, err = AbiParseJSON(typed, []byte(`["0x10", {"field0": "0x487ee5d805b3c95eb23055dc92aad29a89961f17", "field1": "0xa9059cbb"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AbiCoder(argTypes, values); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, input := range []string{
+		`["-1", 1, true, ["0x487ee5d805b3c95eb23055dc92aad29a89961f17", "0xa9059cbb"]]`,
+		`["1", 256, true, ["0x487ee5d805b3c95eb23055dc92aad29a89961f17", "0xa9059cbb"]]`,
+		`["1", 1, true, ["0x1234", "0xa9059cbb"]]`,
+		`["1", 1, true, {"to": "0x487ee5d805b3c95eb23055dc92aad29a89961f17", "sel": "0xa905"}]`,
+		`["1", 1, true]`,
+	} {
+		if _, err := AbiParseJSON(args, []byte(input)); err == nil {
+			t.Fatalf("%s: expected error", input)
+		}
+	}
+}
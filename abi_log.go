@@ -0,0 +1,167 @@
+package laukit
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/laukkw/kwstart/errors"
+	"reflect"
+)
+
+// DecodeLog 按 topic0 找到事件并解析 indexed topics 和 data, 返回事件名和按参数名索引的值
+// 匿名事件没有 topic0, 按 indexed 参数个数和 data 能否解析来匹配, 匹配到多个时返回错误
+// indexed 的 string / bytes / 数组 / tuple 在 topic 中只保存了 hash, 对应的值为 common.Hash
+func DecodeLog(contractABI abi.ABI, log types.Log) (string, map[string]interface{}, error) {
+	event, values, err := decodeLog(contractABI, log)
+	if err != nil {
+		return "", nil, err
+	}
+	result := make(map[string]interface{}, len(values))
+	for i, arg := range event.Inputs {
+		result[abiArgName(arg, i)] = values[i]
+	}
+	return event.Name, result, nil
+}
+
+// DecodeLogInto 同 DecodeLog, 把参数写入 out 指向的结构体
+// 字段通过 `abi:"name"` tag 或参数名的驼峰形式匹配, 没有对应字段的参数被忽略
+func DecodeLogInto(contractABI abi.ABI, log types.Log, out interface{}) (string, error) {
+	event, values, err := decodeLog(contractABI, log)
+	if err != nil {
+		return "", err
+	}
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return "", fmt.Errorf("decode log %s: out must be a pointer to struct", event.Name)
+	}
+	rv = rv.Elem()
+	fields := make(map[string]reflect.Value)
+	for i := 0; i < rv.NumField(); i++ {
+		f := rv.Type().Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if tag, ok := f.Tag.Lookup("abi"); ok {
+			fields[tag] = rv.Field(i)
+		} else {
+			fields[f.Name] = rv.Field(i)
+		}
+	}
+	for i, arg := range event.Inputs {
+		field, ok := fields[arg.Name]
+		if !ok {
+			field, ok = fields[abi.ToCamelCase(abiArgName(arg, i))]
+		}
+		if !ok {
+			continue
+		}
+		if err := setLogField(field, values[i]); err != nil {
+			return "", fmt.Errorf("decode log %s field %s: %w", event.Name, arg.Name, err)
+		}
+	}
+	return event.Name, nil
+}
+
+// decodeLog 返回匹配到的事件和按 Inputs 顺序排列的值
+func decodeLog(contractABI abi.ABI, log types.Log) (*abi.Event, []interface{}, error) {
+	if len(log.Topics) > 0 {
+		if event, err := contractABI.EventByID(log.Topics[0]); err == nil && !event.Anonymous {
+			values, err := decodeLogValues(event, log.Topics[1:], log.Data)
+			if err != nil {
+				return nil, nil, err
+			}
+			return event, values, nil
+		}
+	}
+
+	var matched *abi.Event
+	var matchedValues []interface{}
+	for name := range contractABI.Events {
+		event := contractABI.Events[name]
+		if !event.Anonymous {
+			continue
+		}
+		values, err := decodeLogValues(&event, log.Topics, log.Data)
+		if err != nil {
+			continue
+		}
+		if matched != nil {
+			return nil, nil, fmt.Errorf("decode log: ambiguous anonymous events %s and %s", matched.Sig, event.Sig)
+		}
+		matched, matchedValues = &event, values
+	}
+	if matched == nil {
+		return nil, nil, errors.New("decode log: no matching event in abi")
+	}
+	return matched, matchedValues, nil
+}
+
+func decodeLogValues(event *abi.Event, topics []common.Hash, data []byte) ([]interface{}, error) {
+	var indexed int
+	for _, arg := range event.Inputs {
+		if arg.Indexed {
+			indexed++
+		}
+	}
+	if indexed != len(topics) {
+		return nil, fmt.Errorf("decode log %s: expected %d indexed topics, got %d", event.Name, indexed, len(topics))
+	}
+	nonIndexed, err := event.Inputs.NonIndexed().UnpackValues(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode log %s data: %w", event.Name, err)
+	}
+
+	values := make([]interface{}, 0, len(event.Inputs))
+	var t, d int
+	for _, arg := range event.Inputs {
+		if !arg.Indexed {
+			values = append(values, nonIndexed[d])
+			d++
+			continue
+		}
+		topic := topics[t]
+		t++
+		switch arg.Type.T {
+		case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+			// 动态类型只能拿到 keccak256 hash
+			values = append(values, topic)
+		default:
+			v, err := abi.Arguments{{Type: arg.Type}}.UnpackValues(topic.Bytes())
+			if err != nil {
+				return nil, fmt.Errorf("decode log %s topic %s: %w", event.Name, arg.Name, err)
+			}
+			values = append(values, v[0])
+		}
+	}
+	return values, nil
+}
+
+// abiArgName 没有名字的参数按位置命名为 arg0, arg1 ...
+func abiArgName(arg abi.Argument, i int) string {
+	if arg.Name == "" {
+		return fmt.Sprintf("arg%d", i)
+	}
+	return arg.Name
+}
+
+func setLogField(field reflect.Value, value interface{}) (err error) {
+	v := reflect.ValueOf(value)
+	switch {
+	case !v.IsValid():
+		return nil
+	case v.Type().AssignableTo(field.Type()):
+		field.Set(v)
+	case v.Type().ConvertibleTo(field.Type()):
+		field.Set(v.Convert(field.Type()))
+	default:
+		// tuple 解析出来的是匿名结构体, 交给 abi.ConvertType 按字段转换
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("cannot assign %v to %v", v.Type(), field.Type())
+			}
+		}()
+		field.Set(reflect.ValueOf(abi.ConvertType(value, reflect.New(field.Type()).Interface())).Elem())
+	}
+	return nil
+}
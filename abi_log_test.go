@@ -0,0 +1,74 @@
+package laukit
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"testing"
+)
+
+const logTestABI = `[
+{"anonymous":false,"inputs":[{"indexed":true,"name":"from","type":"address"},{"indexed":true,"name":"to","type":"address"},{"indexed":false,"name":"amount","type":"uint256"}],"name":"Transfer","type":"event"},
+{"anonymous":false,"inputs":[{"indexed":true,"name":"key","type":"string"},{"indexed":false,"name":"value","type":"bytes"}],"name":"Set","type":"event"},
+{"anonymous":true,"inputs":[{"indexed":true,"name":"id","type":"uint256"},{"indexed":false,"name":"ok","type":"bool"}],"name":"Anon","type":"event"}
+]`
+
+func TestDecodeLog(t *testing.T) {
+	contractABI := MustParseABI(logTestABI)
+	from := common.HexToAddress("0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17")
+	to := common.HexToAddress("0x487ee5d805b3c95eb23055dc92aad29a89961f17")
+	amount, _ := AbiCoder([]string{"uint256"}, []interface{}{big.NewInt(2e18)})
+	transfer := types.Log{
+		Topics: []common.Hash{contractABI.Events["Transfer"].ID, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:   amount,
+	}
+
+	t.Run("map", func(t *testing.T) {
+		name, values, err := DecodeLog(contractABI, transfer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != "Transfer" || values["from"] != from || values["to"] != to || values["amount"].(*big.Int).Cmp(big.NewInt(2e18)) != 0 {
+			t.Fatalf("unexpected decoded log %s %v", name, values)
+		}
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		var out struct {
+			From   common.Address
+			To     common.Address `abi:"to"`
+			Amount *big.Int
+		}
+		name, err := DecodeLogInto(contractABI, transfer, &out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != "Transfer" || out.From != from || out.To != to || out.Amount.Cmp(big.NewInt(2e18)) != 0 {
+			t.Fatalf("unexpected decoded struct %+v", out)
+		}
+	})
+
+	t.Run("indexed dynamic", func(t *testing.T) {
+		data, _ := AbiCoder([]string{"bytes"}, []interface{}{[]byte{1, 2}})
+		keyHash := crypto.Keccak256Hash([]byte("owner"))
+		_, values, err := DecodeLog(contractABI, types.Log{Topics: []common.Hash{contractABI.Events["Set"].ID, keyHash}, Data: data})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if values["key"] != keyHash || len(values["value"].([]byte)) != 2 {
+			t.Fatalf("unexpected values %v", values)
+		}
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		data, _ := AbiCoder([]string{"bool"}, []interface{}{true})
+		name, values, err := DecodeLog(contractABI, types.Log{Topics: []common.Hash{common.BigToHash(big.NewInt(7))}, Data: data})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != "Anon" || values["id"].(*big.Int).Int64() != 7 || values["ok"] != true {
+			t.Fatalf("unexpected anonymous log %s %v", name, values)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package laukit
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/laukkw/kwstart/errors"
+	"math/big"
+)
+
+// AbiEncodePacked 按 solidity abi.encodePacked 的规则编码
+//   - 静态类型按实际长度, 不补齐: uint8 1 字节, address 20 字节, bytes4 4 字节
+//   - string / bytes 直接拼接内容, 不带长度
+//   - 数组 (T[] / T[k]) 的每个元素补齐到 32 字节, 不带长度
+//
+// 不支持 tuple 和元素为动态类型的数组, 与 solidity 一致
+func AbiEncodePacked(argTypes []string, argValues []interface{}) ([]byte, error) {
+	return abiEncodePacked(argTypes, argValues, false)
+}
+
+// AbiEncodePackedStrict 同 AbiEncodePacked, 但拒绝包含多个动态长度参数的组合
+// 例如 encodePacked("a", "bc") 与 encodePacked("ab", "c") 结果相同, 用于签名或 hash 时会产生碰撞
+func AbiEncodePackedStrict(argTypes []string, argValues []interface{}) ([]byte, error) {
+	return abiEncodePacked(argTypes, argValues, true)
+}
+
+// SolidityKeccak256 等价于 keccak256(abi.encodePacked(...))
+func SolidityKeccak256(argTypes []string, argValues []interface{}) (common.Hash, error) {
+	packed, err := AbiEncodePacked(argTypes, argValues)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(packed), nil
+}
+
+// SoliditySha256 等价于 sha256(abi.encodePacked(...))
+func SoliditySha256(argTypes []string, argValues []interface{}) (common.Hash, error) {
+	packed, err := AbiEncodePacked(argTypes, argValues)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return sha256.Sum256(packed), nil
+}
+
+func abiEncodePacked(argTypes []string, argValues []interface{}, strict bool) ([]byte, error) {
+	if len(argTypes) != len(argValues) {
+		return nil, errors.New("invalid arguments - types and values do not match")
+	}
+	args, err := buildArgumentsFromTypes(argTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build abi: %v", err)
+	}
+	var packed []byte
+	var dynamic int
+	for i, arg := range args {
+		switch arg.Type.T {
+		case abi.StringTy, abi.BytesTy, abi.SliceTy:
+			dynamic++
+			if strict && dynamic > 1 {
+				return nil, fmt.Errorf("ambiguous packed encoding: argument %d (%s) is the second dynamic-length value", i, arg.Type.String())
+			}
+		}
+		b, err := encodePackedValue(arg.Type, argValues[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d (%s): %w", i, arg.Type.String(), err)
+		}
+		packed = append(packed, b...)
+	}
+	return packed, nil
+}
+
+func encodePackedValue(t abi.Type, value interface{}) ([]byte, error) {
+	switch t.T {
+	case abi.TupleTy:
+		return nil, errors.New("tuple is not supported in packed encoding")
+	case abi.SliceTy, abi.ArrayTy:
+		if !isPackedArrayElem(*t.Elem) {
+			return nil, fmt.Errorf("array of %s is not supported in packed encoding", t.Elem.String())
+		}
+	}
+	// 先按标准编码, 再去掉补齐部分和长度
+	encoded, err := abi.Arguments{{Type: t}}.Pack(value)
+	if err != nil {
+		return nil, err
+	}
+	switch t.T {
+	case abi.StringTy, abi.BytesTy:
+		size := new(big.Int).SetBytes(encoded[32:64]).Int64()
+		return encoded[64 : 64+size], nil
+	case abi.SliceTy:
+		return encoded[64:], nil
+	case abi.ArrayTy:
+		return encoded, nil
+	case abi.FixedBytesTy:
+		return encoded[:t.Size], nil
+	case abi.BoolTy:
+		return encoded[31:], nil
+	case abi.AddressTy:
+		return encoded[12:], nil
+	case abi.IntTy, abi.UintTy:
+		return encoded[32-t.Size/8:], nil
+	}
+	return nil, fmt.Errorf("type %s is not supported in packed encoding", t.String())
+}
+
+// isPackedArrayElem 数组元素只能是静态的基本类型或由它们组成的定长数组
+func isPackedArrayElem(t abi.Type) bool {
+	switch t.T {
+	case abi.ArrayTy:
+		return isPackedArrayElem(*t.Elem)
+	case abi.IntTy, abi.UintTy, abi.BoolTy, abi.AddressTy, abi.FixedBytesTy:
+		return true
+	}
+	return false
+}
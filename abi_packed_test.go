@@ -0,0 +1,75 @@
+package laukit
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestAbiEncodePacked(t *testing.T) {
+	// solidity 文档中的例子
+	packed, err := AbiEncodePacked([]string{"int16", "bytes1", "uint16", "string"},
+		[]interface{}{int16(-1), [1]byte{0x42}, uint16(3), "Hello, world!"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hexutil.Encode(packed) != "0xffff42000348656c6c6f2c20776f726c6421" {
+		t.Fatalf("unexpected packed %x", packed)
+	}
+
+	// 数组元素补齐到 32 字节, address 和 bool 不补齐
+	packed, err = AbiEncodePacked([]string{"address", "bool", "uint16[]", "bytes"},
+		[]interface{}{common.HexToAddress("0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17"), true, []uint16{1, 2}, []byte{0xde, 0xad}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17" + "01" + AddressPadding("01") + AddressPadding("02") + "dead"
+	if hexutil.Encode(packed) != expected {
+		t.Fatalf("unexpected packed %x", packed)
+	}
+
+	if _, err := AbiEncodePacked([]string{"string[]"}, []interface{}{[]string{"a"}}); err == nil {
+		t.Fatal("expected error for array of strings")
+	}
+	if _, err := AbiEncodePacked([]string{"(uint256,bool)"}, []interface{}{struct {
+		Field0 *big.Int
+		Field1 bool
+	}{big.NewInt(1), true}}); err == nil || !strings.Contains(err.Error(), "tuple") {
+		t.Fatalf("expected tuple error, got %v", err)
+	}
+}
+
+func TestAbiEncodePackedStrict(t *testing.T) {
+	a, _ := AbiEncodePacked([]string{"string", "string"}, []interface{}{"a", "bc"})
+	b, _ := AbiEncodePacked([]string{"string", "string"}, []interface{}{"ab", "c"})
+	if string(a) != string(b) {
+		t.Fatal("expected packed collision")
+	}
+	if _, err := AbiEncodePackedStrict([]string{"string", "string"}, []interface{}{"a", "bc"}); err == nil {
+		t.Fatal("expected ambiguous encoding error")
+	}
+	if _, err := AbiEncodePackedStrict([]string{"string", "uint256"}, []interface{}{"a", big.NewInt(1)}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSolidityHash(t *testing.T) {
+	types := []string{"int16", "uint48"}
+	values := []interface{}{int16(-1), big.NewInt(12)}
+	keccak, err := SolidityKeccak256(types, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keccak.Hex() != "0x81da7abb5c9c7515f57dab2fc946f01217ab52f3bd8958bc36bd55894451a93c" {
+		t.Fatalf("unexpected keccak256 %s", keccak.Hex())
+	}
+	sha, err := SoliditySha256(types, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sha.Hex() != "0xa5580fb602f6e2ba9c588011dc4e6c2335e0f5d970dc45869db8f217efc6911a" {
+		t.Fatalf("unexpected sha256 %s", sha.Hex())
+	}
+}
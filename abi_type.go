@@ -0,0 +1,292 @@
+package laukit
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"strings"
+)
+
+// abiArgument 一个参数的类型描述, Type 与 json abi 中的写法一致, tuple 的成员在 Components 中
+type abiArgument struct {
+	Name         string        `json:"name"`
+	Type         string        `json:"type"`
+	InternalType string        `json:"internalType,omitempty"`
+	Indexed      bool          `json:"indexed,omitempty"`
+	Components   []abiArgument `json:"components,omitempty"`
+}
+
+// canonical 返回 solidity 写法的类型, 例如 (address,uint256)[]
+func (a abiArgument) canonical() string {
+	if !strings.HasPrefix(a.Type, "tuple") {
+		return a.Type
+	}
+	parts := make([]string, 0, len(a.Components))
+	for _, c := range a.Components {
+		parts = append(parts, c.canonical())
+	}
+	return "(" + strings.Join(parts, ",") + ")" + strings.TrimPrefix(a.Type, "tuple")
+}
+
+func (a abiArgument) marshaling() []abi.ArgumentMarshaling {
+	if len(a.Components) == 0 {
+		return nil
+	}
+	components := make([]abi.ArgumentMarshaling, 0, len(a.Components))
+	for i, c := range a.Components {
+		name := c.Name
+		if name == "" {
+			// abi.NewType 要求 tuple 成员有名字, 没有名字时按位置命名
+			name = fmt.Sprintf("field%d", i)
+		}
+		components = append(components, abi.ArgumentMarshaling{
+			Name:         name,
+			Type:         c.Type,
+			InternalType: c.InternalType,
+			Components:   c.marshaling(),
+		})
+	}
+	return components
+}
+
+func (a abiArgument) abiType() (abi.Type, error) {
+	return abi.NewType(a.Type, a.InternalType, a.marshaling())
+}
+
+// abiTypeKeywords 类型后面可以出现的修饰词
+var abiTypeKeywords = map[string]bool{
+	"indexed":  true,
+	"memory":   true,
+	"calldata": true,
+	"storage":  true,
+	"payable":  true,
+}
+
+type abiTypeParser struct {
+	expr   string
+	tokens []string
+	pos    int
+}
+
+func newAbiTypeParser(expr string) (*abiTypeParser, error) {
+	p := &abiTypeParser{expr: expr}
+	start := -1
+	for i, c := range expr {
+		isIdent := c == '_' || c == '$' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if isIdent {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			p.tokens = append(p.tokens, expr[start:i])
+			start = -1
+		}
+		switch c {
+		case '(', ')', '[', ']', ',':
+			p.tokens = append(p.tokens, string(c))
+		case ' ', '\t', '\n', '\r':
+		default:
+			return nil, fmt.Errorf("invalid character %q in type expression %q", c, expr)
+		}
+	}
+	if start >= 0 {
+		p.tokens = append(p.tokens, expr[start:])
+	}
+	return p, nil
+}
+
+func (p *abiTypeParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *abiTypeParser) next() string {
+	t := p.peek()
+	if t != "" {
+		p.pos++
+	}
+	return t
+}
+
+func (p *abiTypeParser) expect(t string) error {
+	if got := p.next(); got != t {
+		if got == "" {
+			got = "end of expression"
+		}
+		return fmt.Errorf("expected %q but got %q in type expression %q", t, got, p.expr)
+	}
+	return nil
+}
+
+// parseList 解析逗号分隔的参数, 遇到 ) 或结尾时结束
+func (p *abiTypeParser) parseList() ([]abiArgument, error) {
+	var args []abiArgument
+	if t := p.peek(); t == ")" || t == "" {
+		return args, nil
+	}
+	for {
+		arg, err := p.parseArg()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.peek() != "," {
+			return args, nil
+		}
+		p.next()
+	}
+}
+
+// parseArg 解析 `类型 [修饰词...] [名字]`
+func (p *abiTypeParser) parseArg() (abiArgument, error) {
+	arg, err := p.parseType()
+	if err != nil {
+		return arg, err
+	}
+	for isAbiIdent(p.peek()) {
+		word := p.next()
+		switch {
+		case word == "indexed":
+			arg.Indexed = true
+		case abiTypeKeywords[word]:
+		case arg.Name == "":
+			arg.Name = word
+		default:
+			return arg, fmt.Errorf("unexpected %q after argument %s in type expression %q", word, arg.Name, p.expr)
+		}
+	}
+	return arg, nil
+}
+
+func (p *abiTypeParser) parseType() (abiArgument, error) {
+	var arg abiArgument
+	t := p.next()
+	switch {
+	case t == "(" || (t == "tuple" && p.peek() == "("):
+		if t == "tuple" {
+			p.next()
+		}
+		components, err := p.parseList()
+		if err != nil {
+			return arg, err
+		}
+		if err := p.expect(")"); err != nil {
+			return arg, err
+		}
+		arg.Type, arg.Components = "tuple", components
+	case isAbiIdent(t):
+		arg.Type = normalizeElementaryType(t)
+	case t == "":
+		return arg, fmt.Errorf("unexpected end of type expression %q", p.expr)
+	default:
+		return arg, fmt.Errorf("unexpected %q in type expression %q", t, p.expr)
+	}
+	for p.peek() == "[" {
+		p.next()
+		size := ""
+		if p.peek() != "]" {
+			size = p.next()
+		}
+		if err := p.expect("]"); err != nil {
+			return arg, err
+		}
+		arg.Type += "[" + size + "]"
+	}
+	return arg, nil
+}
+
+func isAbiIdent(t string) bool {
+	if t == "" {
+		return false
+	}
+	switch t[0] {
+	case '(', ')', '[', ']', ',':
+		return false
+	}
+	return true
+}
+
+func normalizeElementaryType(t string) string {
+	switch t {
+	case "uint":
+		return "uint256"
+	case "int":
+		return "int256"
+	case "byte":
+		return "bytes1"
+	}
+	return t
+}
+
+// parseArgumentExpr 解析参数列表, 例如 "address to, (address,uint256)[] items"
+// 整个表达式被一对括号包住时, 括号里的内容作为参数列表
+func parseArgumentExpr(expr string) ([]abiArgument, error) {
+	p, err := newAbiTypeParser(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.tokens) > 1 && p.tokens[0] == "(" && closingParen(p.tokens) == len(p.tokens)-1 {
+		p.tokens = p.tokens[1 : len(p.tokens)-1]
+	}
+	args, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != "" {
+		return nil, fmt.Errorf("unexpected %q in type expression %q", p.peek(), expr)
+	}
+	return args, nil
+}
+
+// parseAbiType 解析单个类型, 例如 "(address,uint256)[]"
+func parseAbiType(expr string) (abiArgument, error) {
+	p, err := newAbiTypeParser(expr)
+	if err != nil {
+		return abiArgument{}, err
+	}
+	arg, err := p.parseArg()
+	if err != nil {
+		return arg, err
+	}
+	if p.peek() != "" {
+		return arg, fmt.Errorf("unexpected %q in type expression %q", p.peek(), expr)
+	}
+	return arg, nil
+}
+
+// closingParen 返回与第一个 ( 匹配的 ) 的位置
+func closingParen(tokens []string) int {
+	depth := 0
+	for i, t := range tokens {
+		switch t {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// buildArgumentsFromExpr 把参数列表表达式转换为 abi.Arguments
+func buildArgumentsFromExpr(expr string) (abi.Arguments, error) {
+	list, err := parseArgumentExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	args := make(abi.Arguments, 0, len(list))
+	for _, arg := range list {
+		abiType, err := arg.abiType()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, abi.Argument{Name: arg.Name, Type: abiType, Indexed: arg.Indexed})
+	}
+	return args, nil
+}
@@ -0,0 +1,84 @@
+package laukit
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"math/big"
+	"testing"
+)
+
+func TestParseArgumentExpr(t *testing.T) {
+	for expr, want := range map[string][]string{
+		"address,address,uint256":                   {"address", "address", "uint256"},
+		"(address,uint256)[]":                       {"(address,uint256)[]"},
+		"((uint8,bytes32),bool)":                    {"(uint8,bytes32)", "bool"},
+		"((uint8,bytes32),bool)[2]":                 {"((uint8,bytes32),bool)[2]"},
+		"address indexed from, uint memory x":       {"address", "uint256"},
+		"tuple(address to, uint256[][] amounts) ps": {"(address,uint256[][])"},
+		"": {},
+	} {
+		args, err := parseArgumentExpr(expr)
+		if err != nil {
+			t.Fatalf("%q: %v", expr, err)
+		}
+		if len(args) != len(want) {
+			t.Fatalf("%q: unexpected args %+v", expr, args)
+		}
+		for i, arg := range args {
+			if arg.canonical() != want[i] {
+				t.Fatalf("%q: arg %d is %s, want %s", expr, i, arg.canonical(), want[i])
+			}
+		}
+	}
+
+	args, _ := parseArgumentExpr("address indexed from, (address to, uint256 amount) memory item")
+	if !args[0].Indexed || args[0].Name != "from" || args[1].Name != "item" || args[1].Components[1].Name != "amount" {
+		t.Fatalf("unexpected args %+v", args)
+	}
+
+	for _, expr := range []string{"(address,uint256", "address,", "uint256[2", "address from to", "address;"} {
+		if _, err := parseArgumentExpr(expr); err == nil {
+			t.Fatalf("%q: expected error", expr)
+		}
+	}
+}
+
+func TestAbiCoderTuple(t *testing.T) {
+	type item struct {
+		Field0 common.Address
+		Field1 *big.Int
+	}
+	items := []item{
+		{common.HexToAddress("0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17"), big.NewInt(1)},
+		{common.HexToAddress("0x487ee5d805b3c95eb23055dc92aad29a89961f17"), big.NewInt(2)},
+	}
+	data, err := AbiCoder([]string{"(address,uint256)[]", "bool"}, []interface{}{items, true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded []item
+	var ok bool
+	if err := AbiDecoder([]string{"(address,uint256)[]", "bool"}, data, []interface{}{&decoded, &ok}); err != nil {
+		t.Fatal(err)
+	}
+	if !ok || len(decoded) != 2 || decoded[1].Field0 != items[1].Field0 || decoded[1].Field1.Int64() != 2 {
+		t.Fatalf("unexpected decoded values %+v %v", decoded, ok)
+	}
+
+	strs, err := AbiDecodeExprAndStringify("(address,uint256)[] items, bool ok", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(strs) != 2 || strs[1] != "true" {
+		t.Fatalf("unexpected strings %v", strs)
+	}
+
+	// 具名 tuple 按字段名输出
+	strs, err = AbiDecodeExprAndStringify("((address to, uint256 amount) order)", MustDecodeString(AddressPadding("01")+AddressPadding("02")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(strs) != 1 || strs[0] != `{"to":"0x0000000000000000000000000000000000000001","amount":"2"}` {
+		t.Fatalf("unexpected named tuple strings %v", strs)
+	}
+}
@@ -6,98 +6,202 @@ import (
     "fmt"
     "github.com/ethereum/go-ethereum/accounts/abi/bind"
     "github.com/ethereum/go-ethereum/common"
+    "github.com/ethereum/go-ethereum/core/types"
     "github.com/ethereum/go-ethereum/crypto"
+    "github.com/laukkw/kwstart/errors"
     "math/big"
+    "strings"
 )
 
 type Eauth struct {
     Private *ecdsa.PrivateKey
+    // Signer 不为空时优先于 Private, 可以是 KeySigner / RemoteSigner 等任意实现
+    Signer Signer
     *Ecl
     context.Context
+    // FeeStrategy 不为空时 transactor 按 EIP-1559 设置 GasTipCap 和 GasFeeCap
+    FeeStrategy FeeStrategy
 }
 
-type EauthOptions func(*Eauth)
+var (
+    // ErrInvalidPrivateKey 私钥不是合法的 32 字节 hex
+    ErrInvalidPrivateKey = errors.New("invalid private key")
+    // ErrEclNotSet 需要访问节点但没有通过 WithEcl 设置客户端
+    ErrEclNotSet = errors.New("client is not set")
+)
+
+// EauthOptions 返回错误时 NewEAuth 失败
+type EauthOptions func(*Eauth) error
 
+// WithPrivateKey hex 私钥, 可以带 0x 前缀
 func WithPrivateKey(private string) EauthOptions {
-    key, _ := crypto.HexToECDSA(private)
-    return func(eauth *Eauth) {
+    return func(eauth *Eauth) error {
+        private = strings.TrimSpace(private)
+        if strings.HasPrefix(private, "0x") || strings.HasPrefix(private, "0X") {
+            private = private[2:]
+        }
+        key, err := crypto.HexToECDSA(private)
+        if err != nil {
+            return fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+        }
         eauth.Private = key
+        return nil
+    }
+}
+
+func WithSigner(signer Signer) EauthOptions {
+    return func(eauth *Eauth) error {
+        if signer == nil {
+            return ErrSignerNotSet
+        }
+        eauth.Signer = signer
+        return nil
     }
 }
 
 func WithEcl(ecl *Ecl) EauthOptions {
-    return func(eauth *Eauth) {
+    return func(eauth *Eauth) error {
+        if ecl == nil {
+            return ErrEclNotSet
+        }
         eauth.Ecl = ecl
+        return nil
     }
 }
 
-func NewEAuth(opts ...EauthOptions) *Eauth {
+func WithFeeStrategy(strategy FeeStrategy) EauthOptions {
+    return func(eauth *Eauth) error {
+        eauth.FeeStrategy = strategy
+        return nil
+    }
+}
+
+// NewEAuth 依次应用 opts, 任意一个失败时返回该错误
+// 私钥和客户端不是必须的, 使用时才检查, 分别返回 ErrSignerNotSet 和 ErrEclNotSet
+func NewEAuth(opts ...EauthOptions) (*Eauth, error) {
     b := &Eauth{}
     for _, o := range opts {
-        o(b)
+        if err := o(b); err != nil {
+            return nil, err
+        }
     }
-    return b
+    return b, nil
 }
 func (e *Eauth) NewTransactor(ctx context.Context) (*bind.TransactOpts, error) {
-    gasPrice, err := e.SuggestGasPrice(ctx)
-    if err != nil {
-        return nil, err
+    if e.Ecl == nil {
+        return nil, ErrEclNotSet
     }
-    auth, err := bind.NewKeyedTransactorWithChainID(e.Private, e.ChainId)
+    signer, err := e.signer()
     if err != nil {
         return nil, err
     }
-    auth.Context = ctx
+    auth := &bind.TransactOpts{
+        From:    signer.Address(),
+        Signer:  e.bindSigner(ctx, signer),
+        Context: ctx,
+    }
     auth.Value = big.NewInt(0)
     auth.GasLimit = 0
-    auth.GasPrice = gasPrice
-    nonce, err := e.GetNonce(ctx)
-    if err != nil {
+    if err := e.applyFees(ctx, auth); err != nil {
         return nil, err
     }
-    auth.Nonce = big.NewInt(int64(nonce))
-    tip, err := e.SuggestGasTipCap(ctx)
+    nonce, err := e.nextNonce(ctx, auth.From)
     if err != nil {
-        return auth, nil
-    } else {
-        auth.GasTipCap = tip
-        auth.GasFeeCap = auth.GasPrice
-        // auth.GasPrice =
+        return nil, err
     }
+    auth.Nonce = big.NewInt(0).SetUint64(nonce)
     return auth, nil
 }
 
 func (e *Eauth) GetNonce(ctx context.Context) (uint64, error) {
-    return e.PendingNonceAt(ctx, crypto.PubkeyToAddress(e.Private.PublicKey))
+    if e.Ecl == nil {
+        return 0, ErrEclNotSet
+    }
+    signer, err := e.signer()
+    if err != nil {
+        return 0, err
+    }
+    return e.PendingNonceAt(ctx, signer.Address())
+}
+
+// signer 返回 e.Signer, 没有设置时用 e.Private 构造 KeySigner
+func (e *Eauth) signer() (Signer, error) {
+    if e.Signer != nil {
+        return e.Signer, nil
+    }
+    if e.Private != nil {
+        return NewKeySigner(e.Private), nil
+    }
+    return nil, ErrSignerNotSet
+}
+
+// bindSigner 把 Signer 转换为 bind.SignerFn, 只允许签名 Signer 自己的地址
+func (e *Eauth) bindSigner(ctx context.Context, signer Signer) bind.SignerFn {
+    return func(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+        if from != signer.Address() {
+            return nil, bind.ErrNotAuthorized
+        }
+        return signer.SignTx(ctx, tx, e.ChainId)
+    }
+}
+
+// nextNonce 配置了 NonceManager 时从本地分配, 否则取节点的 pending nonce
+// 通过 TransactOpts 发送失败的交易需要调用 e.Nonces.Release 归还 nonce
+func (e *Eauth) nextNonce(ctx context.Context, from common.Address) (uint64, error) {
+    if e.Nonces != nil {
+        return e.Nonces.Next(ctx, e.Ecl, from)
+    }
+    return e.PendingNonceAt(ctx, from)
 }
 
 func (e *Eauth) NewTransactorNotPrivateKey(ctx context.Context, from string) (*bind.TransactOpts, error) {
-    if e.Rpc == nil {
-        return nil, fmt.Errorf("not init client")
+    if e.Ecl == nil || e.Rpc == nil {
+        return nil, ErrEclNotSet
     }
-    gasPrice, err := e.SuggestGasPrice(ctx)
-    if err != nil {
+    resp := &bind.TransactOpts{
+        From:    common.HexToAddress(from),
+        Context: ctx,
+        NoSend:  false,
+    }
+    // 配置了同一地址的 Signer 时可以直接用于 bind 发送交易
+    if signer, err := e.signer(); err == nil && signer.Address() == resp.From {
+        resp.Signer = e.bindSigner(ctx, signer)
+    }
+    if err := e.applyFees(ctx, resp); err != nil {
         return nil, err
     }
-    nonce, err := e.PendingNonceAt(ctx, common.HexToAddress(from))
+    nonce, err := e.nextNonce(ctx, resp.From)
     if err != nil {
         return nil, err
     }
-    resp := &bind.TransactOpts{
-        From:     common.HexToAddress(from),
-        Nonce:    big.NewInt(0).SetUint64(nonce),
-        GasPrice: gasPrice,
-        Context:  ctx,
-        NoSend:   false,
+    resp.Nonce = big.NewInt(0).SetUint64(nonce)
+    return resp, nil
+}
+
+// applyFees 按 EIP-1559 设置费用, 没有配置 FeeStrategy 时使用 FeeNormal
+// 默认策略失败时 (节点不支持 eth_feeHistory 或没有 base fee) 退回 legacy GasPrice
+// bind 不允许 GasPrice 与 GasFeeCap / GasTipCap 同时存在
+func (e *Eauth) applyFees(ctx context.Context, opts *bind.TransactOpts) error {
+    strategy := e.FeeStrategy
+    if strategy == nil {
+        strategy = FeeNormal
+    }
+    tip, feeCap, err := strategy.Fees(ctx, e.Ecl)
+    if err == nil {
+        opts.GasPrice = nil
+        opts.GasTipCap = tip
+        opts.GasFeeCap = feeCap
+        return nil
+    }
+    if e.FeeStrategy != nil {
+        return err
     }
-    tip, err := e.SuggestGasTipCap(ctx)
+    gasPrice, err := e.SuggestGasPrice(ctx)
     if err != nil {
-        // 获取失败的话就默认
-        return resp, nil
-    } else {
-        resp.GasTipCap = tip
-        resp.GasFeeCap = resp.GasPrice
-        //resp.GasPrice = nil
+        return err
     }
-    return resp, nil
+    opts.GasPrice = gasPrice
+    opts.GasTipCap = nil
+    opts.GasFeeCap = nil
+    return nil
 }
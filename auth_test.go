@@ -0,0 +1,38 @@
+package laukit
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/laukkw/kwstart/errors"
+	"testing"
+)
+
+func TestNewEAuth(t *testing.T) {
+	const private = "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"
+	for _, key := range []string{private, "0x" + private, " 0X" + private + "\n"} {
+		auth, err := NewEAuth(WithPrivateKey(key))
+		if err != nil {
+			t.Fatalf("%q: %v", key, err)
+		}
+		if crypto.PubkeyToAddress(auth.Private.PublicKey).Hex() != "0x008AeEda4D805471dF9b2A5B0f38A0C3bCBA786b" {
+			t.Fatalf("%q: unexpected address", key)
+		}
+	}
+	for _, key := range []string{"", "0x1234", "zz" + private[2:]} {
+		if _, err := NewEAuth(WithPrivateKey(key)); !errors.Is(err, ErrInvalidPrivateKey) {
+			t.Fatalf("%q: expected invalid private key, got %v", key, err)
+		}
+	}
+	if _, err := NewEAuth(WithPrivateKey(private), WithEcl(nil)); !errors.Is(err, ErrEclNotSet) {
+		t.Fatalf("expected client not set, got %v", err)
+	}
+
+	// 没有客户端时不能构造 transactor
+	auth, _ := NewEAuth(WithPrivateKey(private))
+	if _, err := auth.NewTransactor(context.Background()); !errors.Is(err, ErrEclNotSet) {
+		t.Fatalf("expected client not set, got %v", err)
+	}
+	if _, err := auth.NewTransactorNotPrivateKey(context.Background(), "0x01"); !errors.Is(err, ErrEclNotSet) {
+		t.Fatalf("expected client not set, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package laukit
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"math/big"
+)
+
+// DefaultBatchSize 单个 batch 请求默认最多包含的调用数
+var DefaultBatchSize = 100
+
+// BatchResult 批量请求中单个调用的结果, Execute 之后可用
+type BatchResult[T any] struct {
+	Value T
+	Err   error
+}
+
+// EclBatch 收集多个调用后通过 BatchCallContext 批量发送, 超过 MaxSize 时自动拆分成多个 batch
+type EclBatch struct {
+	ecl     *Ecl
+	MaxSize int
+	elems   []rpc.BatchElem
+	finish  []func(err error)
+}
+
+func NewEclBatch(ecl *Ecl, maxSize int) *EclBatch {
+	if maxSize <= 0 {
+		maxSize = DefaultBatchSize
+	}
+	return &EclBatch{ecl: ecl, MaxSize: maxSize}
+}
+
+// Len 已加入的调用数
+func (b *EclBatch) Len() int {
+	return len(b.elems)
+}
+
+func (b *EclBatch) add(result interface{}, finish func(err error), method string, args ...interface{}) {
+	b.elems = append(b.elems, rpc.BatchElem{Method: method, Args: args, Result: result})
+	b.finish = append(b.finish, finish)
+}
+
+func (b *EclBatch) BalanceAt(account common.Address, blockNumber *big.Int) *BatchResult[*big.Int] {
+	r := &BatchResult[*big.Int]{}
+	var raw hexutil.Big
+	b.add(&raw, func(err error) {
+		r.Value, r.Err = (*big.Int)(&raw), err
+	}, "eth_getBalance", account, toBlockNumArg(blockNumber))
+	return r
+}
+
+func (b *EclBatch) NonceAt(account common.Address, blockNumber *big.Int) *BatchResult[uint64] {
+	r := &BatchResult[uint64]{}
+	var raw hexutil.Uint64
+	b.add(&raw, func(err error) {
+		r.Value, r.Err = uint64(raw), err
+	}, "eth_getTransactionCount", account, toBlockNumArg(blockNumber))
+	return r
+}
+
+func (b *EclBatch) CallContract(msg ethereum.CallMsg, blockNumber *big.Int) *BatchResult[[]byte] {
+	r := &BatchResult[[]byte]{}
+	var raw hexutil.Bytes
+	b.add(&raw, func(err error) {
+		r.Value, r.Err = raw, err
+	}, "eth_call", toCallArg(msg), toBlockNumArg(blockNumber))
+	return r
+}
+
+func (b *EclBatch) CodeAt(account common.Address, blockNumber *big.Int) *BatchResult[[]byte] {
+	r := &BatchResult[[]byte]{}
+	var raw hexutil.Bytes
+	b.add(&raw, func(err error) {
+		r.Value, r.Err = raw, err
+	}, "eth_getCode", account, toBlockNumArg(blockNumber))
+	return r
+}
+
+func (b *EclBatch) StorageAt(account common.Address, key common.Hash, blockNumber *big.Int) *BatchResult[[]byte] {
+	r := &BatchResult[[]byte]{}
+	var raw hexutil.Bytes
+	b.add(&raw, func(err error) {
+		r.Value, r.Err = raw, err
+	}, "eth_getStorageAt", account, key, toBlockNumArg(blockNumber))
+	return r
+}
+
+// Execute 按 MaxSize 分批发送所有调用, 每个调用的错误写入各自的 BatchResult
+// 返回的错误为第一个发送失败的 batch 的错误
+func (b *EclBatch) Execute(ctx context.Context) error {
+	if b.ecl == nil || b.ecl.Rpc == nil {
+		return fmt.Errorf("%s ecl client is nil", errorPath)
+	}
+	var firstErr error
+	for start := 0; start < len(b.elems); start += b.MaxSize {
+		end := start + b.MaxSize
+		if end > len(b.elems) {
+			end = len(b.elems)
+		}
+		chunk := b.elems[start:end]
+		err := b.ecl.Rpc.BatchCallContext(ctx, chunk)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s ecl batch call error: %w", errorPath, err)
+		}
+		for i, elem := range chunk {
+			if err != nil {
+				b.finish[start+i](err)
+			} else {
+				b.finish[start+i](elem.Error)
+			}
+		}
+	}
+	b.elems, b.finish = nil, nil
+	return firstErr
+}
+
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	if number.Sign() >= 0 {
+		return hexutil.EncodeBig(number)
+	}
+	// pending / finalized / safe 等特殊区块
+	text, _ := rpc.BlockNumber(number.Int64()).MarshalText()
+	return string(text)
+}
+
+func toCallArg(msg ethereum.CallMsg) interface{} {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["data"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.GasPrice != nil {
+		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
+	}
+	if msg.GasFeeCap != nil {
+		arg["maxFeePerGas"] = (*hexutil.Big)(msg.GasFeeCap)
+	}
+	if msg.GasTipCap != nil {
+		arg["maxPriorityFeePerGas"] = (*hexutil.Big)(msg.GasTipCap)
+	}
+	if msg.AccessList != nil {
+		arg["accessList"] = msg.AccessList
+	}
+	return arg
+}
@@ -0,0 +1,57 @@
+package laukit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"testing"
+)
+
+func TestEclBatch(t *testing.T) {
+	stub := newStubRPC(t, map[string]stubHandler{
+		"eth_getBalance": func(params []json.RawMessage) (interface{}, error) {
+			var addr common.Address
+			_ = json.Unmarshal(params[0], &addr)
+			if addr == (common.Address{}) {
+				return nil, &stubError{Code: -32000, Message: "bad address"}
+			}
+			return "0xde0b6b3a7640000", nil
+		},
+		"eth_getTransactionCount": func([]json.RawMessage) (interface{}, error) { return "0x9", nil },
+		"eth_call":                func([]json.RawMessage) (interface{}, error) { return "0x0102", nil },
+		"eth_getCode":             func([]json.RawMessage) (interface{}, error) { return "0x6080", nil },
+		"eth_getStorageAt": func([]json.RawMessage) (interface{}, error) {
+			return "0x" + AddressPadding("2a"), nil
+		},
+	})
+	ecl := stub.ecl(t)
+	addr := common.HexToAddress("0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17")
+
+	batch := NewEclBatch(ecl, 2)
+	bal := batch.BalanceAt(addr, nil)
+	bad := batch.BalanceAt(common.Address{}, nil)
+	nonce := batch.NonceAt(addr, nil)
+	call := batch.CallContract(ethereum.CallMsg{To: &addr, Data: []byte{1}}, nil)
+	code := batch.CodeAt(addr, nil)
+	storage := batch.StorageAt(addr, common.Hash{}, nil)
+	if err := batch.Execute(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if bal.Err != nil || WeiToEther(bal.Value.String()).String() != "1" {
+		t.Fatalf("unexpected balance %v %v", bal.Value, bal.Err)
+	}
+	if bad.Err == nil {
+		t.Fatal("expected per-call error")
+	}
+	if nonce.Value != 9 || len(call.Value) != 2 || len(code.Value) != 2 || storage.Value[31] != 0x2a {
+		t.Fatalf("unexpected results %v %v %v %v", nonce.Value, call.Value, code.Value, storage.Value)
+	}
+	if stub.count("eth_getStorageAt") != 1 || batch.Len() != 0 {
+		t.Fatal("unexpected batch state")
+	}
+	// 6 个调用, 每批最多 2 个
+	if sizes := stub.batchSizes(); len(sizes) != 3 || sizes[0] != 2 || sizes[1] != 2 || sizes[2] != 2 {
+		t.Fatalf("unexpected batch chunks %v", sizes)
+	}
+}
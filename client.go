@@ -6,6 +6,8 @@ import (
     "github.com/ethereum/go-ethereum/ethclient"
     "github.com/ethereum/go-ethereum/rpc"
     "math/big"
+    "net/http"
+    "strings"
 )
 
 type Ecl struct {
@@ -13,9 +15,24 @@ type Ecl struct {
     Rpc     *rpc.Client
     Ctx     context.Context
     ChainId *big.Int
+    // Nonces 不为空时, EclNewTransaction 从本地分配 nonce 而不是每次请求 PendingNonceAt
+    Nonces *NonceManager
+    // Pool 由 NewEclPool 创建时不为空
+    Pool *EclPool
+
+    interceptors []RpcInterceptor
+}
+
+type EclOptions func(*Ecl)
+
+// WithNonceManager 为 Ecl 指定本地 nonce 管理器, 多个 Ecl 可以共用同一个
+func WithNonceManager(m *NonceManager) EclOptions {
+    return func(ecl *Ecl) {
+        ecl.Nonces = m
+    }
 }
 
-func NewEcl(ctx context.Context, url string) (*Ecl, error) {
+func NewEcl(ctx context.Context, url string, opts ...EclOptions) (*Ecl, error) {
     if ctx == nil {
         ctx = context.Background()
     }
@@ -23,22 +40,34 @@ func NewEcl(ctx context.Context, url string) (*Ecl, error) {
         return nil, fmt.Errorf("rpc不可为空")
     }
 
-    rpc, err := rpc.Dial(url)
+    ecl := &Ecl{Ctx: ctx}
+    for _, o := range opts {
+        o(ecl)
+    }
+
+    var rpcClient *rpc.Client
+    var err error
+    if len(ecl.interceptors) > 0 {
+        if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+            return nil, fmt.Errorf("rpc middleware 只支持 http(s) 连接")
+        }
+        transport := newRpcTransport(http.DefaultTransport, ecl.interceptors)
+        rpcClient, err = rpc.DialHTTPWithClient(url, &http.Client{Transport: transport})
+    } else {
+        rpcClient, err = rpc.Dial(url)
+    }
     if err != nil {
         return nil, fmt.Errorf("rpc url 连接出错")
     }
-    cli := ethclient.NewClient(rpc)
+    cli := ethclient.NewClient(rpcClient)
     chainId, err := cli.ChainID(ctx)
     if err != nil {
         return nil, fmt.Errorf("rpc url 连接出错")
     }
 
-    ecl := &Ecl{
-        Client:  cli,
-        Rpc:     rpc,
-        Ctx:     ctx,
-        ChainId: chainId,
-    }
+    ecl.Client = cli
+    ecl.Rpc = rpcClient
+    ecl.ChainId = chainId
 
     return ecl, nil
 
@@ -0,0 +1,132 @@
+package laukit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// stubError 由 stub handler 返回, 会被编码成 JSON-RPC error
+type stubError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *stubError) Error() string { return e.Message }
+
+type stubHandler func(params []json.RawMessage) (interface{}, error)
+
+type stubMessage struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type stubResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *stubError      `json:"error,omitempty"`
+}
+
+// stubRPC 是一个最小的 JSON-RPC http 服务, 用于在测试中代替节点
+type stubRPC struct {
+	*httptest.Server
+	mu       sync.Mutex
+	handlers map[string]stubHandler
+	calls    map[string]int
+	// batches 每个批量请求 POST 中的调用个数
+	batches []int
+}
+
+func newStubRPC(t *testing.T, handlers map[string]stubHandler) *stubRPC {
+	t.Helper()
+	s := &stubRPC{handlers: map[string]stubHandler{
+		"eth_chainId": func([]json.RawMessage) (interface{}, error) { return "0x1", nil },
+	}, calls: map[string]int{}}
+	for k, v := range handlers {
+		s.handlers[k] = v
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *stubRPC) handle(method string, h stubHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = h
+}
+
+func (s *stubRPC) count(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+func (s *stubRPC) batchSizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.batches...)
+}
+
+func (s *stubRPC) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	if len(body) > 0 && body[0] == '[' {
+		var msgs []stubMessage
+		_ = json.Unmarshal(body, &msgs)
+		s.mu.Lock()
+		s.batches = append(s.batches, len(msgs))
+		s.mu.Unlock()
+		resps := make([]stubResponse, 0, len(msgs))
+		for _, m := range msgs {
+			resps = append(resps, s.call(m))
+		}
+		_ = json.NewEncoder(w).Encode(resps)
+		return
+	}
+	var m stubMessage
+	_ = json.Unmarshal(body, &m)
+	_ = json.NewEncoder(w).Encode(s.call(m))
+}
+
+func (s *stubRPC) call(m stubMessage) stubResponse {
+	s.mu.Lock()
+	s.calls[m.Method]++
+	h, ok := s.handlers[m.Method]
+	s.mu.Unlock()
+	resp := stubResponse{Version: "2.0", ID: m.ID}
+	if !ok {
+		resp.Error = &stubError{Code: -32601, Message: "the method " + m.Method + " does not exist/is not available"}
+		return resp
+	}
+	result, err := h(m.Params)
+	if err != nil {
+		if se, ok := err.(*stubError); ok {
+			resp.Error = se
+		} else {
+			resp.Error = &stubError{Code: -32000, Message: err.Error()}
+		}
+		return resp
+	}
+	if result == nil {
+		result = json.RawMessage("null")
+	}
+	resp.Result = result
+	return resp
+}
+
+func (s *stubRPC) ecl(t *testing.T, opts ...EclOptions) *Ecl {
+	t.Helper()
+	ecl, err := NewEcl(context.Background(), s.URL, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ecl
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/laukkw/laukit"
+	"github.com/shopspring/decimal"
+	"strings"
+)
+
+// runSig 输出函数的规范签名和 4 字节选择器, topic 为完整的 keccak256, 用于事件签名
+func runSig(inv *invocation) error {
+	method, err := parseFunction(inv.args[0])
+	if err != nil {
+		return err
+	}
+	selector := laukit.FunctionSignature(method.Sig)
+	topic := crypto.Keccak256Hash([]byte(method.Sig)).Hex()
+	return inv.output(map[string]string{
+		"signature": method.Sig,
+		"selector":  selector,
+		"topic":     topic,
+	}, selector)
+}
+
+// runEncode 按类型编码 JSON 数组中的值
+func runEncode(inv *invocation) error {
+	args, err := laukit.ParseArguments(inv.args[0])
+	if err != nil {
+		return err
+	}
+	data, err := packJSON(args, inv.args[1])
+	if err != nil {
+		return err
+	}
+	encoded := hexutil.Encode(data)
+	return inv.output(map[string]string{"data": encoded}, encoded)
+}
+
+// runDecode 按类型解码数据, 默认每行一个值, -json 时输出 AbiMarshalJSON 的格式
+func runDecode(inv *invocation) error {
+	data, err := decodeHex(inv.args[1])
+	if err != nil {
+		return err
+	}
+	if !inv.json {
+		values, err := laukit.AbiDecodeExprAndStringify(inv.args[0], data)
+		if err != nil {
+			return err
+		}
+		return inv.output(nil, values...)
+	}
+	args, err := laukit.ParseArguments(inv.args[0])
+	if err != nil {
+		return err
+	}
+	values, err := args.UnpackValues(data)
+	if err != nil {
+		return err
+	}
+	out, err := laukit.AbiMarshalJSON(args, values)
+	if err != nil {
+		return err
+	}
+	return inv.output(map[string]json.RawMessage{"values": out})
+}
+
+// runCalldata 编码函数调用数据: 选择器 ‖ 参数, 第二个参数是 0x 开头的 hex 时按签名解码
+func runCalldata(inv *invocation) error {
+	method, err := parseFunction(inv.args[0])
+	if err != nil {
+		return err
+	}
+	values := "[]"
+	if len(inv.args) > 1 && inv.args[1] != "" {
+		values = inv.args[1]
+	}
+	if strings.HasPrefix(values, "0x") {
+		return decodeCalldata(inv, method, values)
+	}
+	data, err := packJSON(method.Inputs, values)
+	if err != nil {
+		return err
+	}
+	calldata := hexutil.Encode(append(method.ID, data...))
+	return inv.output(map[string]string{
+		"signature": method.Sig,
+		"selector":  hexutil.Encode(method.ID),
+		"data":      calldata,
+	}, calldata)
+}
+
+func decodeCalldata(inv *invocation, method abi.Method, input string) error {
+	data, err := decodeHex(input)
+	if err != nil {
+		return err
+	}
+	if len(data) < 4 || !bytes.Equal(data[:4], method.ID) {
+		return fmt.Errorf("calldata does not start with %s selector %s", method.Sig, hexutil.Encode(method.ID))
+	}
+	values, err := method.Inputs.UnpackValues(data[4:])
+	if err != nil {
+		return err
+	}
+	if inv.json {
+		out, err := laukit.AbiMarshalJSON(method.Inputs, values)
+		if err != nil {
+			return err
+		}
+		return inv.output(map[string]interface{}{"signature": method.Sig, "values": json.RawMessage(out)})
+	}
+	lines, err := laukit.StringifyArgumentValues(method.Inputs, values)
+	if err != nil {
+		return err
+	}
+	return inv.output(nil, lines...)
+}
+
+func runToWei(inv *invocation) error {
+	amount, err := parseAmount(inv)
+	if err != nil {
+		return err
+	}
+	wei := laukit.SmallToBigEthers(amount.String(), inv.decimals)
+	if !wei.IsInteger() {
+		return fmt.Errorf("amount %s has more than %d decimal places", amount, inv.decimals)
+	}
+	return inv.output(map[string]string{"wei": wei.String()}, wei.String())
+}
+
+func runFromWei(inv *invocation) error {
+	amount, err := parseAmount(inv)
+	if err != nil {
+		return err
+	}
+	if !amount.IsInteger() {
+		return fmt.Errorf("wei amount %s is not an integer", amount)
+	}
+	value := laukit.BigToSmallEthers(amount.String(), inv.decimals)
+	return inv.output(map[string]string{"value": value.String()}, value.String())
+}
+
+// parseAmount 解析 to-wei / from-wei 的数量, 数量和 -decimals 都不能为负数
+func parseAmount(inv *invocation) (decimal.Decimal, error) {
+	if inv.decimals < 0 {
+		return decimal.Decimal{}, fmt.Errorf("invalid decimals %d", inv.decimals)
+	}
+	amount, err := decimal.NewFromString(inv.args[0])
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("invalid amount %q: %w", inv.args[0], err)
+	}
+	if amount.IsNegative() {
+		return decimal.Decimal{}, fmt.Errorf("amount %s is negative", amount)
+	}
+	return amount, nil
+}
+
+// runPad 左补 0 到 32 字节, 用于地址或整数作为 topic / 存储槽
+func runPad(inv *invocation) error {
+	input := strings.TrimSpace(inv.args[0])
+	if len(strings.TrimPrefix(input, "0x")) > 64 {
+		return fmt.Errorf("input %s is longer than 32 bytes", input)
+	}
+	padded := "0x" + laukit.AddressPadding(input)
+	if _, err := decodeHex(padded); err != nil {
+		return err
+	}
+	return inv.output(map[string]string{"padded": padded}, padded)
+}
+
+// parseFunction 解析 "transfer(address to, uint256 amount)", 可以带 function 前缀和 returns
+func parseFunction(sig string) (abi.Method, error) {
+	sig = strings.TrimSpace(sig)
+	if !strings.HasPrefix(sig, "function ") {
+		sig = "function " + sig
+	}
+	parsed, err := laukit.ParseHumanABI(sig)
+	if err != nil {
+		return abi.Method{}, err
+	}
+	if len(parsed.Methods) != 1 {
+		return abi.Method{}, fmt.Errorf("invalid function signature %q", sig)
+	}
+	for _, method := range parsed.Methods {
+		return method, nil
+	}
+	return abi.Method{}, nil
+}
+
+func packJSON(args abi.Arguments, values string) ([]byte, error) {
+	parsed, err := laukit.AbiParseJSON(args, []byte(values))
+	if err != nil {
+		return nil, err
+	}
+	return args.Pack(parsed...)
+}
+
+// decodeHex 解析 hex, 0x 前缀可选
+func decodeHex(input string) ([]byte, error) {
+	input = strings.TrimPrefix(strings.TrimSpace(input), "0x")
+	data, err := hexutil.Decode("0x" + input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex %q: %w", input, err)
+	}
+	return data, nil
+}
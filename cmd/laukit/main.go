@@ -0,0 +1,149 @@
+// laukit 命令行工具, 常用的编码 / 解码 / 单位转换
+//
+//	laukit sig "transfer(address to, uint256 amount)"
+//	laukit encode "address,uint256" '["0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17", "1000"]'
+//	laukit decode "address,uint256" 0x000...
+//	laukit calldata "transfer(address,uint256)" '["0x8c43...", "1000"]'
+//	laukit calldata "transfer(address,uint256)" 0xa9059cbb...
+//	laukit to-wei 1.5
+//	laukit from-wei 1500000000000000000
+//	laukit pad 0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17
+//
+// 最后一个参数省略或为 "-" 时从标准输入读取, -json 输出 JSON, flag 可以放在位置参数前后
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type command struct {
+	usage string
+	// args 需要的参数个数, optional 个末尾参数可以省略
+	args     int
+	optional int
+	run      func(cmd *invocation) error
+}
+
+// invocation 一次命令调用的参数和输入输出
+type invocation struct {
+	args     []string
+	json     bool
+	decimals int64
+	stdout   io.Writer
+}
+
+var commands = map[string]command{
+	"sig":      {usage: "sig <function signature>", args: 1, run: runSig},
+	"encode":   {usage: "encode <types> <json values>", args: 2, run: runEncode},
+	"decode":   {usage: "decode <types> <hex data>", args: 2, run: runDecode},
+	"calldata": {usage: "calldata <function signature> [json values | hex calldata]", args: 2, optional: 1, run: runCalldata},
+	"to-wei":   {usage: "to-wei [-decimals n] <amount>", args: 1, run: runToWei},
+	"from-wei": {usage: "from-wei [-decimals n] <amount>", args: 1, run: runFromWei},
+	"pad":      {usage: "pad <hex>", args: 1, run: runPad},
+}
+
+var commandOrder = []string{"sig", "encode", "decode", "calldata", "to-wei", "from-wei", "pad"}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "laukit:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "help" {
+		fmt.Fprint(stdout, usage())
+		return nil
+	}
+	name := args[0]
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n%s", name, usage())
+	}
+
+	inv := &invocation{stdout: stdout}
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.BoolVar(&inv.json, "json", false, "output json")
+	flags.Int64Var(&inv.decimals, "decimals", 18, "token decimals for to-wei / from-wei")
+	flagArgs, positional := splitFlags(flags, args[1:])
+	if err := flags.Parse(flagArgs); err != nil {
+		return fmt.Errorf("%v\nusage: laukit %s", err, cmd.usage)
+	}
+
+	required := cmd.args - cmd.optional
+	// 最后一个参数为 "-" 或者少了最后一个必需参数时从标准输入读取
+	if n := len(positional); (n > 0 && positional[n-1] == "-") || n == required-1 {
+		if n > 0 && positional[n-1] == "-" {
+			positional = positional[:n-1]
+		}
+		input, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		positional = append(positional, strings.TrimSpace(string(input)))
+	}
+	if len(positional) < required || len(positional) > cmd.args {
+		return fmt.Errorf("usage: laukit %s", cmd.usage)
+	}
+	inv.args = positional
+	return cmd.run(inv)
+}
+
+// splitFlags 把参数分成 flag 和位置参数, flag 可以出现在任意位置, "--" 之后都是位置参数
+func splitFlags(flags *flag.FlagSet, args []string) (flagArgs, positional []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return flagArgs, append(positional, args[i+1:]...)
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			positional = append(positional, arg)
+			continue
+		}
+		flagArgs = append(flagArgs, arg)
+		name := strings.TrimLeft(arg, "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		// 非 bool flag 的值是下一个参数
+		if f := flags.Lookup(name); f != nil && i+1 < len(args) {
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+				i++
+				flagArgs = append(flagArgs, args[i])
+			}
+		}
+	}
+	return flagArgs, positional
+}
+
+func usage() string {
+	var b strings.Builder
+	b.WriteString("usage: laukit <command> [-json] [args]\n\ncommands:\n")
+	for _, name := range commandOrder {
+		fmt.Fprintf(&b, "  %s\n", commands[name].usage)
+	}
+	b.WriteString("\nthe last argument is read from stdin when omitted or \"-\"\n")
+	return b.String()
+}
+
+// output -json 时输出 value, 否则逐行输出 lines
+func (inv *invocation) output(value interface{}, lines ...string) error {
+	if inv.json {
+		encoder := json.NewEncoder(inv.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(inv.stdout, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const transferData = "0x0000000000000000000000008c43fbebaa2ded5a50c10766b0f03a151f2bbf17" +
+	"00000000000000000000000000000000000000000000000000000000000003e8"
+
+func runCLI(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	err := run(args, strings.NewReader(stdin), &out)
+	return strings.TrimSpace(out.String()), err
+}
+
+func TestRun(t *testing.T) {
+	for _, c := range []struct {
+		args     []string
+		stdin    string
+		expected string
+	}{
+		{[]string{"sig", "transfer(address to, uint256 amount)"}, "", "0xa9059cbb"},
+		{[]string{"sig", "function balanceOf(address owner) view returns (uint256)"}, "", "0x70a08231"},
+		{[]string{"sig"}, "transfer(address,uint256)\n", "0xa9059cbb"},
+		{[]string{"encode", "address,uint256", `["0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17", "1000"]`}, "", transferData},
+		{[]string{"encode", "(address to, uint256 amount)", "-"}, `["0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17", 1000]`, transferData},
+		{[]string{"decode", "address,uint256", transferData}, "", "0x8C43FbebAA2dED5a50C10766b0F03a151f2bBf17\n1000"},
+		{[]string{"decode", "address,uint256"}, transferData[2:], "0x8C43FbebAA2dED5a50C10766b0F03a151f2bBf17\n1000"},
+		{[]string{"decode", "((address to, uint256 amount) order)", transferData}, "", `{"to":"0x8C43FbebAA2dED5a50C10766b0F03a151f2bBf17","amount":"1000"}`},
+		{[]string{"calldata", "transfer(address,uint256)", `["0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17", "1000"]`}, "", "0xa9059cbb" + transferData[2:]},
+		{[]string{"calldata", "totalSupply()"}, "", "0x18160ddd"},
+		{[]string{"calldata", "transfer(address,uint256)", "0xa9059cbb" + transferData[2:]}, "", "0x8C43FbebAA2dED5a50C10766b0F03a151f2bBf17\n1000"},
+		{[]string{"to-wei", "1.5"}, "", "1500000000000000000"},
+		{[]string{"to-wei", "-decimals", "6"}, "2.25", "2250000"},
+		{[]string{"to-wei", "2.25", "-decimals", "6"}, "", "2250000"},
+		{[]string{"from-wei", "1500000000000000000"}, "", "1.5"},
+		{[]string{"from-wei", "-decimals", "6", "1"}, "", "0.000001"},
+		{[]string{"pad", "0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17"}, "", "0x0000000000000000000000008c43fbebaa2ded5a50c10766b0f03a151f2bbf17"},
+		{[]string{"pad", "3e8"}, "", "0x00000000000000000000000000000000000000000000000000000000000003e8"},
+	} {
+		out, err := runCLI(t, c.stdin, c.args...)
+		if err != nil {
+			t.Fatalf("%v: %v", c.args, err)
+		}
+		if out != c.expected {
+			t.Fatalf("%v: got %q, want %q", c.args, out, c.expected)
+		}
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	// flag 也可以放在位置参数后面
+	out, err := runCLI(t, "", "sig", "Transfer(address,address,uint256)", "-json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sig map[string]string
+	if err := json.Unmarshal([]byte(out), &sig); err != nil {
+		t.Fatal(err)
+	}
+	if sig["selector"] != "0xddf252ad" || sig["topic"] != "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef" {
+		t.Fatalf("unexpected sig output %v", sig)
+	}
+
+	out, err = runCLI(t, "", "decode", "-json", "(address to, uint256 amount)[]",
+		"0x0000000000000000000000000000000000000000000000000000000000000020"+
+			"0000000000000000000000000000000000000000000000000000000000000001"+transferData[2:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Values []json.RawMessage `json:"values"`
+	}
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Values) != 1 || strings.Join(strings.Fields(string(decoded.Values[0])), "") !=
+		`[{"to":"0x8C43FbebAA2dED5a50C10766b0F03a151f2bBf17","amount":"1000"}]` {
+		t.Fatalf("unexpected decode output %s", out)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"unknown"},
+		{"sig", "transfer(address"},
+		{"encode", "uint8", "[256]"},
+		{"encode", "address"},
+		{"decode", "uint256", "0xzz"},
+		{"calldata", "transfer(address,uint256)", "0x12345678"},
+		{"to-wei", "abc"},
+		{"to-wei", "0.0000000000000000001"},
+		{"to-wei", "--", "-1.5"},
+		{"to-wei", "-decimals", "-1", "1"},
+		{"from-wei", "1.5"},
+		{"from-wei", "--", "-1"},
+		{"from-wei", "-decimals", "-6", "1"},
+		{"pad", "zz"},
+		{"pad", "0x" + strings.Repeat("1", 66)},
+		{"pad", "0x1", "0x2"},
+	} {
+		if _, err := runCLI(t, "", args...); err == nil {
+			t.Fatalf("%v: expected error", args)
+		}
+	}
+	if out, err := runCLI(t, ""); err != nil || !strings.HasPrefix(out, "usage:") {
+		t.Fatalf("unexpected usage output %q %v", out, err)
+	}
+}
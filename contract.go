@@ -0,0 +1,72 @@
+package laukit
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+)
+
+// EclCallContract 以 eth_call 调用合约的只读方法, 返回按 outputs 解析的值
+// blockNumber 为空时查询最新区块, revert 数据按 contractABI 中的自定义 error 解析
+func EclCallContract(ctx context.Context, ecl *Ecl, to common.Address, contractABI abi.ABI, method string, blockNumber *big.Int, args ...interface{}) ([]interface{}, error) {
+	if ecl == nil {
+		return nil, fmt.Errorf("%s ecl client is nil", errorPath)
+	}
+	m, ok := contractABI.Methods[method]
+	if !ok {
+		return nil, fmt.Errorf("contract method %s not found", method)
+	}
+	input, err := EncodeInputData(contractABI, method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s error: %w", m.Sig, err)
+	}
+	output, err := ecl.CallContract(ctx, ethereum.CallMsg{To: &to, Data: input}, blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("call %s error: %w", m.Sig, DecodeCallError(err, contractABI))
+	}
+	values, err := m.Outputs.UnpackValues(output)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s output error: %w", m.Sig, err)
+	}
+	return values, nil
+}
+
+// EclSendContractTransaction 调用合约的写方法: 通过 EclNewTransaction 构造交易, 用 auth 的 Signer 签名后广播
+// value 为空时不转 ETH, 估算 gas 失败时 revert 数据按 contractABI 中的自定义 error 解析
+func EclSendContractTransaction(ctx context.Context, ecl *Ecl, auth *Eauth, to common.Address, value *big.Int, contractABI abi.ABI, method string, args ...interface{}) (*types.Transaction, WaitReceipt, error) {
+	if ecl == nil || auth == nil {
+		return nil, nil, fmt.Errorf("%s contract transaction error: client or auth is nil", errorPath)
+	}
+	signer, err := auth.signer()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s contract transaction error: %w", errorPath, err)
+	}
+	data, err := EncodeInputData(contractABI, method, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s encode %s error: %w", errorPath, method, err)
+	}
+	req := &TransactionReq{
+		From:        signer.Address(),
+		To:          to,
+		ETHValue:    value,
+		Data:        data,
+		FeeStrategy: auth.FeeStrategy,
+		ErrorABI:    &contractABI,
+	}
+	rawTx, err := EclNewTransaction(ctx, ecl, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	signTx, err := signer.SignTx(ctx, rawTx, ecl.ChainId)
+	if err != nil {
+		if ecl.Nonces != nil {
+			ecl.Nonces.Release(ecl, req.From, rawTx.Nonce())
+		}
+		return nil, nil, fmt.Errorf("%s sign %s error: %w", errorPath, method, err)
+	}
+	return EclSendTransaction(ctx, ecl, signTx)
+}
@@ -0,0 +1,83 @@
+package laukit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"math/big"
+	"testing"
+)
+
+// stubContractMethod 处理一个合约方法, 返回值按 outputs 编码, 返回 nil 表示空的返回数据
+type stubContractMethod func(to common.Address, args []interface{}) ([]interface{}, error)
+
+// stubContract 按选择器把 eth_call 分发到 methods
+func stubContract(t *testing.T, contractABI abi.ABI, methods map[string]stubContractMethod) stubHandler {
+	return func(params []json.RawMessage) (interface{}, error) {
+		var msg struct {
+			To    common.Address `json:"to"`
+			Data  hexutil.Bytes  `json:"data"`
+			Input hexutil.Bytes  `json:"input"`
+		}
+		if err := json.Unmarshal(params[0], &msg); err != nil {
+			return nil, err
+		}
+		if len(msg.Data) == 0 {
+			msg.Data = msg.Input
+		}
+		m, err := contractABI.MethodById(msg.Data[:4])
+		if err != nil {
+			return nil, &stubError{Code: 3, Message: "execution reverted"}
+		}
+		h, ok := methods[m.Name]
+		if !ok {
+			return nil, &stubError{Code: 3, Message: "execution reverted"}
+		}
+		args, err := m.Inputs.UnpackValues(msg.Data[4:])
+		if err != nil {
+			t.Errorf("unpack %s: %v", m.Name, err)
+			return nil, err
+		}
+		values, err := h(msg.To, args)
+		if err != nil || values == nil {
+			return hexutil.Bytes{}, err
+		}
+		out, err := m.Outputs.Pack(values...)
+		if err != nil {
+			t.Errorf("pack %s: %v", m.Name, err)
+			return nil, err
+		}
+		return hexutil.Bytes(out), nil
+	}
+}
+
+func TestEclCallContract(t *testing.T) {
+	token := MustParseABI(multicallTestABI)
+	stub := newStubRPC(t, map[string]stubHandler{
+		"eth_call": stubContract(t, token, map[string]stubContractMethod{
+			"balanceOf": func(to common.Address, args []interface{}) ([]interface{}, error) {
+				return []interface{}{new(big.Int).SetBytes(args[0].(common.Address).Bytes())}, nil
+			},
+		}),
+	})
+	ecl := stub.ecl(t)
+	values, err := EclCallContract(context.Background(), ecl, common.HexToAddress("0x01"), token, "balanceOf", nil, common.HexToAddress("0x0102"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[0].(*big.Int).Int64() != 0x0102 {
+		t.Fatalf("unexpected balance %v", values[0])
+	}
+	if _, err := EclCallContract(context.Background(), ecl, common.HexToAddress("0x01"), token, "transfer", nil); err == nil {
+		t.Fatal("expected unknown method error")
+	}
+	if _, err := EclCallContract(context.Background(), nil, common.HexToAddress("0x01"), token, "balanceOf", nil, common.HexToAddress("0x0102")); err == nil {
+		t.Fatal("expected nil client error")
+	}
+	auth, _ := NewEAuth(WithPrivateKey("0x1234567890123456789012345678901234567890123456789012345678901234"))
+	if _, err := EclBuildPermit(context.Background(), nil, auth, common.HexToAddress("0x01"), common.HexToAddress("0x02"), big.NewInt(1), big.NewInt(1)); err == nil {
+		t.Fatal("expected nil client error")
+	}
+}
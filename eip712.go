@@ -0,0 +1,124 @@
+package laukit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/laukkw/kwstart/errors"
+)
+
+// TypedData EIP-712 结构化数据, 与 eth_signTypedData_v4 的 JSON 格式一致
+type TypedData = apitypes.TypedData
+
+type TypedDataDomain = apitypes.TypedDataDomain
+
+type TypedDataField = apitypes.Type
+
+const eip712DomainType = "EIP712Domain"
+
+// ParseTypedData 解析 eth_signTypedData_v4 格式的 JSON
+// types 中没有 EIP712Domain 时按 domain 中出现的字段补上
+func ParseTypedData(data []byte) (*TypedData, error) {
+	var typedData TypedData
+	if err := json.Unmarshal(data, &typedData); err != nil {
+		return nil, fmt.Errorf("invalid typed data: %w", err)
+	}
+	return completeTypedData(&typedData)
+}
+
+// DomainSeparator 返回 hashStruct(domain)
+func DomainSeparator(typedData *TypedData) (common.Hash, error) {
+	typedData, err := completeTypedData(typedData)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	hash, err := typedData.HashStruct(eip712DomainType, typedData.Domain.Map())
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("hash eip712 domain: %w", err)
+	}
+	return common.BytesToHash(hash), nil
+}
+
+// TypedDataHash 返回签名用的摘要 keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message))
+func TypedDataHash(typedData *TypedData) (common.Hash, error) {
+	domainSeparator, err := DomainSeparator(typedData)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("hash eip712 %s: %w", typedData.PrimaryType, err)
+	}
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator.Bytes(), messageHash), nil
+}
+
+// SignTypedData 用 e.Signer (没有时用 e.Private) 签名, 返回 65 字节的 r ‖ s ‖ v, v 为 27 或 28, 与 eth_signTypedData_v4 一致
+func (e *Eauth) SignTypedData(ctx context.Context, typedData *TypedData) ([]byte, error) {
+	signer, err := e.signer()
+	if err != nil {
+		return nil, fmt.Errorf("sign typed data: %w", err)
+	}
+	return signer.SignTypedData(ctx, typedData)
+}
+
+// RecoverTypedDataSigner 从签名恢复签名地址, v 可以是 0/1 或 27/28, 也可以是 EIP-2098 的 64 字节签名
+func RecoverTypedDataSigner(typedData *TypedData, sig []byte) (common.Address, error) {
+	hash, err := TypedDataHash(typedData)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return recoverHashSigner(hash.Bytes(), sig)
+}
+
+func recoverHashSigner(hash []byte, sig []byte) (common.Address, error) {
+	normalized, err := normalizeSignature(sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	pub, err := crypto.SigToPub(hash, normalized)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// completeTypedData 补全 EIP712Domain 类型定义
+// 需要补全时返回浅拷贝, Types 为新的 map, 不修改调用方传入的 typedData
+func completeTypedData(typedData *TypedData) (*TypedData, error) {
+	if typedData.Types == nil {
+		return nil, errors.New("invalid typed data: types is empty")
+	}
+	if _, ok := typedData.Types[typedData.PrimaryType]; !ok {
+		return nil, fmt.Errorf("invalid typed data: primary type %q is not defined", typedData.PrimaryType)
+	}
+	if _, ok := typedData.Types[eip712DomainType]; ok {
+		return typedData, nil
+	}
+	var fields []TypedDataField
+	domain := typedData.Domain
+	if domain.Name != "" {
+		fields = append(fields, TypedDataField{Name: "name", Type: "string"})
+	}
+	if domain.Version != "" {
+		fields = append(fields, TypedDataField{Name: "version", Type: "string"})
+	}
+	if domain.ChainId != nil {
+		fields = append(fields, TypedDataField{Name: "chainId", Type: "uint256"})
+	}
+	if domain.VerifyingContract != "" {
+		fields = append(fields, TypedDataField{Name: "verifyingContract", Type: "address"})
+	}
+	if domain.Salt != "" {
+		fields = append(fields, TypedDataField{Name: "salt", Type: "bytes32"})
+	}
+	completed := *typedData
+	completed.Types = make(apitypes.Types, len(typedData.Types)+1)
+	for name, fields := range typedData.Types {
+		completed.Types[name] = fields
+	}
+	completed.Types[eip712DomainType] = fields
+	return &completed, nil
+}
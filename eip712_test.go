@@ -0,0 +1,139 @@
+package laukit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"strings"
+	"testing"
+)
+
+// EIP-712 规范中的 Mail 例子
+const eip712MailJSON = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func TestTypedDataHash(t *testing.T) {
+	typedData, err := ParseTypedData([]byte(eip712MailJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	domainSeparator, err := DomainSeparator(typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if domainSeparator.Hex() != "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f" {
+		t.Fatalf("unexpected domain separator %s", domainSeparator.Hex())
+	}
+	hash, err := TypedDataHash(typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash.Hex() != "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2" {
+		t.Fatalf("unexpected digest %s", hash.Hex())
+	}
+
+	// 没有 EIP712Domain 定义时按 domain 字段补全
+	withoutDomain := strings.Replace(eip712MailJSON, `"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],`, "", 1)
+	typedData, err = ParseTypedData([]byte(withoutDomain))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completed, _ := TypedDataHash(typedData); completed != hash {
+		t.Fatalf("unexpected digest %s", completed.Hex())
+	}
+
+	// 补全时不修改调用方的 typedData
+	var raw TypedData
+	_ = json.Unmarshal([]byte(withoutDomain), &raw)
+	if completed, _ := TypedDataHash(&raw); completed != hash {
+		t.Fatalf("unexpected digest %s", completed.Hex())
+	}
+	if separator, _ := DomainSeparator(&raw); separator != domainSeparator {
+		t.Fatalf("unexpected domain separator %s", separator.Hex())
+	}
+	if _, ok := raw.Types[eip712DomainType]; ok || len(raw.Types) != 2 {
+		t.Fatalf("typed data types modified %v", raw.Types)
+	}
+
+	if _, err := ParseTypedData([]byte(`{"types": {}, "primaryType": "Mail"}`)); err == nil {
+		t.Fatal("expected undefined primary type error")
+	}
+}
+
+func TestSignTypedData(t *testing.T) {
+	typedData, _ := ParseTypedData([]byte(eip712MailJSON))
+	key, _ := crypto.ToECDSA(crypto.Keccak256([]byte("cow")))
+	auth, _ := NewEAuth()
+	auth.Private = key
+
+	sig, err := auth.SignTypedData(context.Background(), typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d" +
+		"07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562" + "1c"
+	if hexutil.Encode(sig) != expected {
+		t.Fatalf("unexpected signature %x", sig)
+	}
+
+	signer, err := RecoverTypedDataSigner(typedData, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer != common.HexToAddress("0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826") {
+		t.Fatalf("unexpected signer %s", signer.Hex())
+	}
+	sig[64] -= 27
+	if signer, _ := RecoverTypedDataSigner(typedData, sig); signer != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("unexpected signer with v 0/1 %s", signer.Hex())
+	}
+
+	// 嵌套结构体数组
+	typedData.Types["Mail"] = append(typedData.Types["Mail"], TypedDataField{Name: "cc", Type: "Person[]"})
+	typedData.Message["cc"] = []interface{}{
+		map[string]interface{}{"name": "Alice", "wallet": "0xaAaAaAaaAaAaAaaAaAAAAAAAAaaaAaAaAaaAaaAa"},
+	}
+	sig, err = auth.SignTypedData(context.Background(), typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer, _ := RecoverTypedDataSigner(typedData, sig); signer != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("unexpected signer %s", signer.Hex())
+	}
+}
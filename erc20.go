@@ -0,0 +1,181 @@
+package laukit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/laukkw/kwstart/errors"
+	"github.com/shopspring/decimal"
+	"math/big"
+	"sync"
+)
+
+// ErrERC20ReturnedFalse 代币方法返回 false, 交易会被执行但转账没有发生
+var ErrERC20ReturnedFalse = errors.New("erc20 call returned false")
+
+var erc20ABI = MustParseHumanABI(
+	"function name() view returns (string)",
+	"function symbol() view returns (string)",
+	"function decimals() view returns (uint8)",
+	"function totalSupply() view returns (uint256)",
+	"function balanceOf(address owner) view returns (uint256)",
+	"function allowance(address owner, address spender) view returns (uint256)",
+	"function transfer(address to, uint256 amount) returns (bool)",
+	"function approve(address spender, uint256 amount) returns (bool)",
+	"function transferFrom(address from, address to, uint256 amount) returns (bool)",
+	"event Transfer(address indexed from, address indexed to, uint256 value)",
+	"event Approval(address indexed owner, address indexed spender, uint256 value)",
+)
+
+// ERC20 绑定到一个代币地址的客户端, 数量按 decimals 换算为可读的小数
+type ERC20 struct {
+	ecl     *Ecl
+	Address common.Address
+
+	mu       sync.Mutex
+	decimals *uint8
+}
+
+func NewERC20(ecl *Ecl, address common.Address) *ERC20 {
+	return &ERC20{ecl: ecl, Address: address}
+}
+
+// Decimals 第一次查询后缓存
+func (t *ERC20) Decimals(ctx context.Context) (uint8, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.decimals != nil {
+		return *t.decimals, nil
+	}
+	values, err := EclCallContract(ctx, t.ecl, t.Address, erc20ABI, "decimals", nil)
+	if err != nil {
+		return 0, fmt.Errorf("erc20 %s: %w", t.Address.Hex(), err)
+	}
+	decimals := values[0].(uint8)
+	t.decimals = &decimals
+	return decimals, nil
+}
+
+// Name 兼容返回 bytes32 的老代币
+func (t *ERC20) Name(ctx context.Context) (string, error) {
+	return t.callString(ctx, "name")
+}
+
+// Symbol 兼容返回 bytes32 的老代币, 例如 MKR
+func (t *ERC20) Symbol(ctx context.Context) (string, error) {
+	return t.callString(ctx, "symbol")
+}
+
+func (t *ERC20) TotalSupply(ctx context.Context) (decimal.Decimal, error) {
+	return t.callAmount(ctx, "totalSupply")
+}
+
+func (t *ERC20) BalanceOf(ctx context.Context, owner common.Address) (decimal.Decimal, error) {
+	return t.callAmount(ctx, "balanceOf", owner)
+}
+
+func (t *ERC20) Allowance(ctx context.Context, owner, spender common.Address) (decimal.Decimal, error) {
+	return t.callAmount(ctx, "allowance", owner, spender)
+}
+
+// Transfer 用 auth 签名并广播 transfer, amount 为可读的数量, 例如 1.5
+func (t *ERC20) Transfer(ctx context.Context, auth *Eauth, to common.Address, amount decimal.Decimal) (*types.Transaction, WaitReceipt, error) {
+	return t.send(ctx, auth, "transfer", amount, to)
+}
+
+func (t *ERC20) Approve(ctx context.Context, auth *Eauth, spender common.Address, amount decimal.Decimal) (*types.Transaction, WaitReceipt, error) {
+	return t.send(ctx, auth, "approve", amount, spender)
+}
+
+func (t *ERC20) TransferFrom(ctx context.Context, auth *Eauth, from, to common.Address, amount decimal.Decimal) (*types.Transaction, WaitReceipt, error) {
+	return t.send(ctx, auth, "transferFrom", amount, from, to)
+}
+
+// ToUnits 把可读数量换算为最小单位, 小数位超过 decimals 时返回错误
+func (t *ERC20) ToUnits(ctx context.Context, amount decimal.Decimal) (*big.Int, error) {
+	decimals, err := t.Decimals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	units := SmallToBigEthers(amount.String(), int64(decimals))
+	if !units.Equal(units.Truncate(0)) {
+		return nil, fmt.Errorf("erc20 %s: amount %s has more than %d decimals", t.Address.Hex(), amount, decimals)
+	}
+	if units.Sign() < 0 {
+		return nil, fmt.Errorf("erc20 %s: negative amount %s", t.Address.Hex(), amount)
+	}
+	return units.BigInt(), nil
+}
+
+// FromUnits 把最小单位换算为可读数量
+func (t *ERC20) FromUnits(ctx context.Context, units *big.Int) (decimal.Decimal, error) {
+	decimals, err := t.Decimals(ctx)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return BigToSmallEthers(units.String(), int64(decimals)), nil
+}
+
+func (t *ERC20) callAmount(ctx context.Context, method string, args ...interface{}) (decimal.Decimal, error) {
+	values, err := EclCallContract(ctx, t.ecl, t.Address, erc20ABI, method, nil, args...)
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("erc20 %s: %w", t.Address.Hex(), err)
+	}
+	return t.FromUnits(ctx, values[0].(*big.Int))
+}
+
+func (t *ERC20) callString(ctx context.Context, method string) (string, error) {
+	input, _ := EncodeInputData(erc20ABI, method)
+	output, err := t.ecl.CallContract(ctx, ethereum.CallMsg{To: &t.Address, Data: input}, nil)
+	if err != nil {
+		return "", fmt.Errorf("erc20 %s %s error: %w", t.Address.Hex(), method, DecodeCallError(err))
+	}
+	if len(output) == 32 {
+		// bytes32 右侧补零
+		return string(bytes.TrimRight(output, "\x00")), nil
+	}
+	values, err := erc20ABI.Methods[method].Outputs.UnpackValues(output)
+	if err != nil {
+		return "", fmt.Errorf("erc20 %s decode %s error: %w", t.Address.Hex(), method, err)
+	}
+	return values[0].(string), nil
+}
+
+// send 广播前先用 eth_call 模拟, 返回 false 时不发送
+// 不返回 bool 的代币 (例如 USDT) 返回数据为空, 视为成功
+func (t *ERC20) send(ctx context.Context, auth *Eauth, method string, amount decimal.Decimal, args ...interface{}) (*types.Transaction, WaitReceipt, error) {
+	if auth == nil {
+		return nil, nil, fmt.Errorf("erc20 %s %s: auth is nil", t.Address.Hex(), method)
+	}
+	signer, err := auth.signer()
+	if err != nil {
+		return nil, nil, fmt.Errorf("erc20 %s %s: %w", t.Address.Hex(), method, err)
+	}
+	units, err := t.ToUnits(ctx, amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	args = append(args, units)
+	input, err := EncodeInputData(erc20ABI, method, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	from := signer.Address()
+	output, err := t.ecl.CallContract(ctx, ethereum.CallMsg{From: from, To: &t.Address, Data: input}, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erc20 %s %s error: %w", t.Address.Hex(), method, DecodeCallError(err))
+	}
+	if len(output) > 0 {
+		values, err := erc20ABI.Methods[method].Outputs.UnpackValues(output)
+		if err != nil {
+			return nil, nil, fmt.Errorf("erc20 %s decode %s error: %w", t.Address.Hex(), method, err)
+		}
+		if !values[0].(bool) {
+			return nil, nil, fmt.Errorf("erc20 %s %s: %w", t.Address.Hex(), method, ErrERC20ReturnedFalse)
+		}
+	}
+	return EclSendContractTransaction(ctx, t.ecl, auth, t.Address, nil, erc20ABI, method, args...)
+}
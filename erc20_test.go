@@ -0,0 +1,123 @@
+package laukit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/laukkw/kwstart/errors"
+	"github.com/shopspring/decimal"
+	"math/big"
+	"testing"
+)
+
+func TestERC20(t *testing.T) {
+	ctx := context.Background()
+	owner := common.HexToAddress("0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17")
+	spender := common.HexToAddress("0x487ee5d805b3c95eb23055dc92aad29a89961f17")
+	var decimalsCalls int
+	var transferResult []interface{}
+	methods := map[string]stubContractMethod{
+		"decimals": func(common.Address, []interface{}) ([]interface{}, error) {
+			decimalsCalls++
+			return []interface{}{uint8(6)}, nil
+		},
+		"symbol": func(common.Address, []interface{}) ([]interface{}, error) {
+			return []interface{}{"USDC"}, nil
+		},
+		"balanceOf": func(_ common.Address, args []interface{}) ([]interface{}, error) {
+			if args[0].(common.Address) != owner {
+				return []interface{}{big.NewInt(0)}, nil
+			}
+			return []interface{}{big.NewInt(1500000)}, nil
+		},
+		"allowance": func(common.Address, []interface{}) ([]interface{}, error) {
+			return []interface{}{big.NewInt(1)}, nil
+		},
+		"transfer": func(common.Address, []interface{}) ([]interface{}, error) {
+			return transferResult, nil
+		},
+	}
+	var sent []*types.Transaction
+	stub := newStubRPC(t, map[string]stubHandler{
+		"eth_call":                stubContract(t, erc20ABI, methods),
+		"eth_getTransactionCount": func([]json.RawMessage) (interface{}, error) { return "0x7", nil },
+		"eth_gasPrice":            func([]json.RawMessage) (interface{}, error) { return "0x1", nil },
+		"eth_estimateGas":         func([]json.RawMessage) (interface{}, error) { return "0xc350", nil },
+		"eth_sendRawTransaction": func(params []json.RawMessage) (interface{}, error) {
+			var raw hexutil.Bytes
+			_ = json.Unmarshal(params[0], &raw)
+			tx := new(types.Transaction)
+			if err := tx.UnmarshalBinary(raw); err != nil {
+				return nil, err
+			}
+			sent = append(sent, tx)
+			return tx.Hash(), nil
+		},
+	})
+	token := NewERC20(stub.ecl(t), common.HexToAddress("0xaa"))
+
+	balance, err := token.BalanceOf(ctx, owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance.Equal(decimal.RequireFromString("1.5")) {
+		t.Fatalf("unexpected balance %s", balance)
+	}
+	if allowance, _ := token.Allowance(ctx, owner, spender); !allowance.Equal(decimal.RequireFromString("0.000001")) {
+		t.Fatalf("unexpected allowance %s", allowance)
+	}
+	if decimalsCalls != 1 {
+		t.Fatalf("decimals should be cached, called %d times", decimalsCalls)
+	}
+	if symbol, err := token.Symbol(ctx); err != nil || symbol != "USDC" {
+		t.Fatalf("unexpected symbol %q %v", symbol, err)
+	}
+
+	// 返回 bytes32 的 symbol
+	bytes32Token := MustParseHumanABI("function symbol() view returns (bytes32)")
+	stub.handle("eth_call", stubContract(t, bytes32Token, map[string]stubContractMethod{
+		"symbol": func(common.Address, []interface{}) ([]interface{}, error) {
+			return []interface{}{[32]byte{'M', 'K', 'R'}}, nil
+		},
+	}))
+	if symbol, err := token.Symbol(ctx); err != nil || symbol != "MKR" {
+		t.Fatalf("unexpected bytes32 symbol %q %v", symbol, err)
+	}
+	stub.handle("eth_call", stubContract(t, erc20ABI, methods))
+
+	key, _ := crypto.ToECDSA(crypto.Keccak256([]byte("cow")))
+	auth, _ := NewEAuth(WithEcl(token.ecl))
+	auth.Private = key
+
+	if _, _, err := token.Transfer(ctx, auth, spender, decimal.RequireFromString("0.0000001")); err == nil {
+		t.Fatal("expected too many decimals error")
+	}
+
+	// 不返回 bool 的代币
+	tx, wait, err := token.Transfer(ctx, auth, spender, decimal.RequireFromString("1.25"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait == nil || len(sent) != 1 || sent[0].Hash() != tx.Hash() || *tx.To() != token.Address || tx.Nonce() != 7 {
+		t.Fatalf("unexpected transaction %+v", tx)
+	}
+	name, args, err := DecodeInputData(erc20ABI, tx.Data())
+	if err != nil || name != "transfer" || args["to"] != spender || args["amount"].(*big.Int).Int64() != 1250000 {
+		t.Fatalf("unexpected calldata %s %v %v", name, args, err)
+	}
+
+	transferResult = []interface{}{false}
+	if _, _, err := token.Transfer(ctx, auth, spender, decimal.NewFromInt(1)); !errors.Is(err, ErrERC20ReturnedFalse) {
+		t.Fatalf("expected returned false error, got %v", err)
+	}
+	transferResult = []interface{}{true}
+	if _, _, err := token.Transfer(ctx, auth, spender, decimal.NewFromInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 {
+		t.Fatalf("unexpected sent transactions %d", len(sent))
+	}
+}
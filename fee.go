@@ -0,0 +1,84 @@
+package laukit
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// FeeStrategy 计算 EIP-1559 交易的 tip 和 fee cap
+type FeeStrategy interface {
+	Fees(ctx context.Context, ecl *Ecl) (tip *big.Int, feeCap *big.Int, err error)
+}
+
+// FeeHistoryStrategy 根据 eth_feeHistory 计算费用:
+// tip 取最近 Blocks 个区块 reward 百分位的中位数, fee cap 为预估 BaseFeeBlocks 个区块后的 base fee 加上 tip
+type FeeHistoryStrategy struct {
+	Blocks        uint64
+	Percentile    float64
+	BaseFeeBlocks int
+	// MinTip 不为空时 tip 不低于该值
+	MinTip *big.Int
+}
+
+var (
+	FeeSlow   = FeeHistoryStrategy{Blocks: 20, Percentile: 10, BaseFeeBlocks: 1}
+	FeeNormal = FeeHistoryStrategy{Blocks: 20, Percentile: 50, BaseFeeBlocks: 3}
+	FeeFast   = FeeHistoryStrategy{Blocks: 20, Percentile: 90, BaseFeeBlocks: 6}
+)
+
+// base fee 每个区块最多上涨 12.5%
+var (
+	baseFeeChangeNumerator   = big.NewInt(1125)
+	baseFeeChangeDenominator = big.NewInt(1000)
+)
+
+func (s FeeHistoryStrategy) Fees(ctx context.Context, ecl *Ecl) (*big.Int, *big.Int, error) {
+	if ecl == nil {
+		return nil, nil, fmt.Errorf("%s fee strategy: ecl client is nil", errorPath)
+	}
+	blocks := s.Blocks
+	if blocks == 0 {
+		blocks = 1
+	}
+	history, err := ecl.FeeHistory(ctx, blocks, nil, []float64{s.Percentile})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s ecl fee history error: %w", errorPath, err)
+	}
+	if len(history.BaseFee) == 0 {
+		return nil, nil, fmt.Errorf("%s ecl fee history error: 节点没有返回 base fee", errorPath)
+	}
+
+	var rewards []*big.Int
+	for i, reward := range history.Reward {
+		// 空块的 reward 为 0, 不参与计算
+		if i < len(history.GasUsedRatio) && history.GasUsedRatio[i] == 0 {
+			continue
+		}
+		if len(reward) > 0 && reward[0] != nil {
+			rewards = append(rewards, reward[0])
+		}
+	}
+	var tip *big.Int
+	if len(rewards) > 0 {
+		sort.Slice(rewards, func(i, j int) bool { return rewards[i].Cmp(rewards[j]) < 0 })
+		tip = new(big.Int).Set(rewards[len(rewards)/2])
+	} else {
+		tip, err = ecl.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s ecl get gas tip error: %w", errorPath, err)
+		}
+	}
+	if s.MinTip != nil && tip.Cmp(s.MinTip) < 0 {
+		tip = new(big.Int).Set(s.MinTip)
+	}
+
+	// 最后一个 base fee 是下一个区块的
+	baseFee := new(big.Int).Set(history.BaseFee[len(history.BaseFee)-1])
+	for i := 0; i < s.BaseFeeBlocks; i++ {
+		baseFee.Mul(baseFee, baseFeeChangeNumerator)
+		baseFee.Div(baseFee, baseFeeChangeDenominator)
+	}
+	return tip, baseFee.Add(baseFee, tip), nil
+}
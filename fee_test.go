@@ -0,0 +1,194 @@
+package laukit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"testing"
+)
+
+func feeHistoryStub(t *testing.T) *stubRPC {
+	return newStubRPC(t, map[string]stubHandler{
+		"eth_feeHistory": func(params []json.RawMessage) (interface{}, error) {
+			return map[string]interface{}{
+				"oldestBlock":   "0x10",
+				"baseFeePerGas": []string{"0x3b9aca00", "0x3b9aca00", "0x3b9aca00", "0x3b9aca00", "0x77359400"},
+				"gasUsedRatio":  []float64{0.5, 0, 0.9, 0.3},
+				"reward":        [][]string{{"0x64"}, {"0x0"}, {"0x12c"}, {"0xc8"}},
+			}, nil
+		},
+		"eth_getTransactionCount": func([]json.RawMessage) (interface{}, error) { return "0x7", nil },
+		"eth_estimateGas":         func([]json.RawMessage) (interface{}, error) { return "0x5208", nil },
+	})
+}
+
+func TestFeeHistoryStrategy(t *testing.T) {
+	ecl := feeHistoryStub(t).ecl(t)
+
+	tip, feeCap, err := FeeNormal.Fees(context.Background(), ecl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 空块被跳过, 剩下 100, 300, 200 取中位数
+	if tip.Cmp(big.NewInt(200)) != 0 {
+		t.Fatalf("unexpected tip %v", tip)
+	}
+	// 下一个区块 base fee 2 gwei, 预估 3 个区块每块上涨 12.5%
+	want := big.NewInt(2_000_000_000)
+	for i := 0; i < 3; i++ {
+		want.Mul(want, big.NewInt(1125))
+		want.Div(want, big.NewInt(1000))
+	}
+	want.Add(want, tip)
+	if feeCap.Cmp(want) != 0 {
+		t.Fatalf("unexpected fee cap %v, want %v", feeCap, want)
+	}
+
+	slowTip, slowCap, err := FeeHistoryStrategy{Blocks: 4, BaseFeeBlocks: 0, MinTip: big.NewInt(1000)}.Fees(context.Background(), ecl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slowTip.Int64() != 1000 || slowCap.Int64() != 2_000_001_000 {
+		t.Fatalf("unexpected fees %v %v", slowTip, slowCap)
+	}
+}
+
+func TestEclNewTransactionFeeStrategy(t *testing.T) {
+	ecl := feeHistoryStub(t).ecl(t)
+	tx, err := EclNewTransaction(context.Background(), ecl, &TransactionReq{
+		From:        common.HexToAddress("0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17"),
+		To:          common.HexToAddress("0x487ee5d805b3c95eb23055dc92aad29a89961f17"),
+		ETHValue:    big.NewInt(1),
+		FeeStrategy: FeeFast,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Type() != 2 || tx.GasTipCap().Int64() != 200 || tx.Nonce() != 7 {
+		t.Fatalf("unexpected tx type %d tip %v nonce %d", tx.Type(), tx.GasTipCap(), tx.Nonce())
+	}
+
+	key, _ := crypto.GenerateKey()
+	auth, _ := NewEAuth(WithEcl(ecl), WithFeeStrategy(FeeSlow))
+	auth.Private = key
+	opts, err := auth.NewTransactor(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.GasPrice != nil || opts.GasFeeCap == nil || opts.GasTipCap.Int64() != 200 {
+		t.Fatalf("unexpected transactor fees %v %v %v", opts.GasPrice, opts.GasFeeCap, opts.GasTipCap)
+	}
+}
+
+// 没有配置 FeeStrategy 时默认 FeeNormal, 节点不支持 eth_feeHistory 时退回 legacy, 两种情况都能通过 bind 发送
+func TestNewTransactorDefaultFees(t *testing.T) {
+	contract := common.HexToAddress("0xaa")
+	contractABI := MustParseHumanABI("function set(uint256 value)")
+	for _, legacy := range []bool{false, true} {
+		var sent []*types.Transaction
+		stub := feeHistoryStub(t)
+		stub.handle("eth_gasPrice", func([]json.RawMessage) (interface{}, error) { return "0x3b9aca00", nil })
+		stub.handle("eth_getCode", func([]json.RawMessage) (interface{}, error) { return "0x6080", nil })
+		stub.handle("eth_sendRawTransaction", func(params []json.RawMessage) (interface{}, error) {
+			var raw hexutil.Bytes
+			_ = json.Unmarshal(params[0], &raw)
+			tx := new(types.Transaction)
+			if err := tx.UnmarshalBinary(raw); err != nil {
+				return nil, err
+			}
+			sent = append(sent, tx)
+			return tx.Hash(), nil
+		})
+		if legacy {
+			stub.handle("eth_feeHistory", func([]json.RawMessage) (interface{}, error) {
+				return nil, &stubError{Code: -32601, Message: "the method eth_feeHistory does not exist/is not available"}
+			})
+		}
+		ecl := stub.ecl(t)
+		key, _ := crypto.GenerateKey()
+		auth, _ := NewEAuth(WithEcl(ecl))
+		auth.Private = key
+		opts, err := auth.NewTransactor(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		bound := bind.NewBoundContract(contract, contractABI, ecl, ecl, ecl)
+		if _, err := bound.Transact(opts, "set", big.NewInt(1)); err != nil {
+			t.Fatalf("legacy %v: %v", legacy, err)
+		}
+		if len(sent) != 1 {
+			t.Fatalf("legacy %v: unexpected sent transactions %d", legacy, len(sent))
+		}
+		if tx := sent[0]; legacy && (tx.Type() != types.LegacyTxType || tx.GasPrice().Int64() != 1_000_000_000) {
+			t.Fatalf("unexpected legacy tx type %d gas price %v", tx.Type(), tx.GasPrice())
+		} else if !legacy && (tx.Type() != types.DynamicFeeTxType || tx.GasTipCap().Int64() != 200 || tx.Nonce() != 7) {
+			t.Fatalf("unexpected tx type %d tip %v nonce %d", tx.Type(), tx.GasTipCap(), tx.Nonce())
+		}
+	}
+}
+
+// EclNewTransaction 与 NewTransactor 使用同样的默认费用: FeeNormal, 节点不支持时退回 legacy
+func TestEclNewTransactionDefaultFees(t *testing.T) {
+	ctx := context.Background()
+	from := common.HexToAddress("0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17")
+	to := common.HexToAddress("0x487ee5d805b3c95eb23055dc92aad29a89961f17")
+	stub := feeHistoryStub(t)
+	stub.handle("eth_gasPrice", func([]json.RawMessage) (interface{}, error) { return "0x3b9aca00", nil })
+	ecl := stub.ecl(t)
+	_, normalCap, _ := FeeNormal.Fees(ctx, ecl)
+
+	tx, err := EclNewTransaction(ctx, ecl, &TransactionReq{From: from, To: to, ETHValue: big.NewInt(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Type() != types.DynamicFeeTxType || tx.GasTipCap().Int64() != 200 || tx.GasFeeCap().Cmp(normalCap) != 0 {
+		t.Fatalf("unexpected default fees type %d tip %v cap %v", tx.Type(), tx.GasTipCap(), tx.GasFeeCap())
+	}
+	// 只指定 GasTip 时 fee cap 按 base fee 加上该 tip 计算
+	tx, err = EclNewTransaction(ctx, ecl, &TransactionReq{From: from, To: to, ETHValue: big.NewInt(1), GasTip: big.NewInt(5_000_000_000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCap := new(big.Int).Add(new(big.Int).Sub(normalCap, big.NewInt(200)), big.NewInt(5_000_000_000))
+	if tx.GasTipCap().Int64() != 5_000_000_000 || tx.GasFeeCap().Cmp(wantCap) != 0 {
+		t.Fatalf("unexpected fees tip %v cap %v, want cap %v", tx.GasTipCap(), tx.GasFeeCap(), wantCap)
+	}
+
+	// 合约写方法默认也是 EIP-1559 交易
+	var sent *types.Transaction
+	stub.handle("eth_sendRawTransaction", func(params []json.RawMessage) (interface{}, error) {
+		var raw hexutil.Bytes
+		_ = json.Unmarshal(params[0], &raw)
+		sent = new(types.Transaction)
+		return common.Hash{}, sent.UnmarshalBinary(raw)
+	})
+	key, _ := crypto.GenerateKey()
+	auth, _ := NewEAuth(WithEcl(ecl))
+	auth.Private = key
+	if _, _, err := EclSendContractTransaction(ctx, ecl, auth, to, nil, MustParseHumanABI("function set(uint256 value)"), "set", big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if sent == nil || sent.Type() != types.DynamicFeeTxType {
+		t.Fatalf("unexpected contract transaction %v", sent)
+	}
+
+	stub.handle("eth_feeHistory", func([]json.RawMessage) (interface{}, error) {
+		return nil, &stubError{Code: -32601, Message: "the method eth_feeHistory does not exist/is not available"}
+	})
+	tx, err = EclNewTransaction(ctx, ecl, &TransactionReq{From: from, To: to, ETHValue: big.NewInt(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Type() != types.LegacyTxType || tx.GasPrice().Int64() != 1_000_000_000 {
+		t.Fatalf("unexpected legacy fallback type %d gas price %v", tx.Type(), tx.GasPrice())
+	}
+	// 显式配置的策略失败时不退回
+	if _, err := EclNewTransaction(ctx, ecl, &TransactionReq{From: from, To: to, ETHValue: big.NewInt(1), FeeStrategy: FeeFast}); err == nil {
+		t.Fatal("expected fee strategy error")
+	}
+}
@@ -4,8 +4,10 @@ go 1.19
 
 require (
 	github.com/ethereum/go-ethereum v1.11.5
+	github.com/google/uuid v1.3.0
 	github.com/laukkw/kwstart v1.0.0
 	github.com/shopspring/decimal v1.3.1
+	github.com/tyler-smith/go-bip39 v1.1.0
 )
 
 require (
@@ -17,7 +19,6 @@ require (
 	github.com/fsnotify/fsnotify v1.6.0 // indirect
 	github.com/go-ole/go-ole v1.2.1 // indirect
 	github.com/go-stack/stack v1.8.1 // indirect
-	github.com/google/uuid v1.3.0 // indirect
 	github.com/gorilla/websocket v1.4.2 // indirect
 	github.com/holiman/uint256 v1.2.0 // indirect
 	github.com/shirou/gopsutil v3.21.4-0.20210419000835-c7a38de76ee5+incompatible // indirect
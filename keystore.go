@@ -0,0 +1,193 @@
+package laukit
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// KeystoreScryptN / KeystoreScryptP 新建 keystore 时的 scrypt 参数, 默认与 geth 一致 (约 256MB 内存)
+var (
+	KeystoreScryptN = keystore.StandardScryptN
+	KeystoreScryptP = keystore.StandardScryptP
+)
+
+// DecryptKeystore 解密 Web3 Secret Storage v3 (scrypt 或 pbkdf2) 格式的 keystore
+func DecryptKeystore(keyJSON []byte, passphrase string) (*ecdsa.PrivateKey, error) {
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return key.PrivateKey, nil
+}
+
+// EncryptKeystore 用 scrypt 加密私钥, 返回 v3 keystore JSON
+func EncryptKeystore(private *ecdsa.PrivateKey, passphrase string) ([]byte, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	key := &keystore.Key{
+		Id:         id,
+		Address:    crypto.PubkeyToAddress(private.PublicKey),
+		PrivateKey: private,
+	}
+	return keystore.EncryptKey(key, passphrase, KeystoreScryptN, KeystoreScryptP)
+}
+
+// LoadKeystore 读取并解密 keystore 文件
+func LoadKeystore(path, passphrase string) (*ecdsa.PrivateKey, error) {
+	keyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := DecryptKeystore(keyJSON, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("load keystore %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// SaveKeystore 加密私钥并写入 path, 文件权限为 0600
+func SaveKeystore(path string, private *ecdsa.PrivateKey, passphrase string) error {
+	keyJSON, err := EncryptKeystore(private, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, keyJSON, 0o600)
+}
+
+// WithKeystore 从 keystore 文件加载私钥, 密码错误时返回 keystore.ErrDecrypt
+func WithKeystore(path, passphrase string) EauthOptions {
+	return func(eauth *Eauth) error {
+		key, err := LoadKeystore(path, passphrase)
+		if err != nil {
+			return err
+		}
+		eauth.Private = key
+		return nil
+	}
+}
+
+// ExportKeystore 把 e.Private 导出为 v3 keystore JSON
+func (e *Eauth) ExportKeystore(passphrase string) ([]byte, error) {
+	if e.Private == nil {
+		return nil, fmt.Errorf("export keystore: private key is not set")
+	}
+	return EncryptKeystore(e.Private, passphrase)
+}
+
+// KeystoreDir 以目录保存 keystore 文件, 文件名与 geth 一致: UTC--<时间>--<地址>
+type KeystoreDir struct {
+	Dir string
+}
+
+func NewKeystoreDir(dir string) *KeystoreDir {
+	return &KeystoreDir{Dir: dir}
+}
+
+// Accounts 按地址排序返回目录中所有 keystore 的地址, 不需要密码
+// 没有 address 字段或无法解析的文件被忽略
+func (d *KeystoreDir) Accounts() ([]common.Address, error) {
+	files, err := d.files()
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]common.Address, 0, len(files))
+	for addr := range files {
+		addresses = append(addresses, addr)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return strings.Compare(addresses[i].Hex(), addresses[j].Hex()) < 0
+	})
+	return addresses, nil
+}
+
+// Import 加密私钥并保存到目录, 返回地址
+func (d *KeystoreDir) Import(private *ecdsa.PrivateKey, passphrase string) (common.Address, error) {
+	addr := crypto.PubkeyToAddress(private.PublicKey)
+	files, err := d.files()
+	if err != nil && !os.IsNotExist(err) {
+		return addr, err
+	}
+	if _, ok := files[addr]; ok {
+		return addr, fmt.Errorf("keystore for %s already exists", addr.Hex())
+	}
+	ts := time.Now().UTC().Format("2006-01-02T15-04-05.000000000Z")
+	name := fmt.Sprintf("UTC--%s--%s", ts, strings.ToLower(addr.Hex()[2:]))
+	return addr, SaveKeystore(filepath.Join(d.Dir, name), private, passphrase)
+}
+
+// Load 按地址找到 keystore 并解密
+func (d *KeystoreDir) Load(addr common.Address, passphrase string) (*ecdsa.PrivateKey, error) {
+	files, err := d.files()
+	if err != nil {
+		return nil, err
+	}
+	path, ok := files[addr]
+	if !ok {
+		return nil, fmt.Errorf("keystore for %s not found in %s", addr.Hex(), d.Dir)
+	}
+	key, err := LoadKeystore(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if crypto.PubkeyToAddress(key.PublicKey) != addr {
+		return nil, fmt.Errorf("keystore %s does not contain key for %s", path, addr.Hex())
+	}
+	return key, nil
+}
+
+// Eauth 返回使用 addr 私钥的 Eauth, opts 用于设置 Ecl 等其它选项
+func (d *KeystoreDir) Eauth(addr common.Address, passphrase string, opts ...EauthOptions) (*Eauth, error) {
+	key, err := d.Load(addr, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := NewEAuth(opts...)
+	if err != nil {
+		return nil, err
+	}
+	auth.Private = key
+	return auth, nil
+}
+
+// files 返回地址到文件路径的映射
+func (d *KeystoreDir) files() (map[common.Address]string, error) {
+	entries, err := os.ReadDir(d.Dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[common.Address]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") {
+			continue
+		}
+		path := filepath.Join(d.Dir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var header struct {
+			Address string `json:"address"`
+		}
+		if err := json.Unmarshal(data, &header); err != nil || !common.IsHexAddress(header.Address) {
+			continue
+		}
+		files[common.HexToAddress(header.Address)] = path
+	}
+	return files, nil
+}
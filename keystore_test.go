@@ -0,0 +1,102 @@
+package laukit
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/laukkw/kwstart/errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Web3 Secret Storage 规范中的测试向量, 与 geth accounts/keystore/testdata 相同
+var keystoreTestVectors = map[string]string{
+	"scrypt": `{"crypto":{"cipher":"aes-128-ctr","cipherparams":{"iv":"83dbcc02d8ccb40e466191a123791e0e"},` +
+		`"ciphertext":"d172bf743a674da9cdad04534d56926ef8358534d458fffccd4e6ad2fbde479c","kdf":"scrypt",` +
+		`"kdfparams":{"dklen":32,"n":262144,"r":1,"p":8,"salt":"ab0c7876052600dd703518d6fc3fe8984592145b591fc8fb5c6d43190334ba19"},` +
+		`"mac":"2103ac29920d71da29f15d75b4a16dbe95cfd7ff8faea1056c33131d846e3097"},"id":"3198bc9c-6672-5ab3-d995-4942343ae5b6","version":3}`,
+	"pbkdf2": `{"crypto":{"cipher":"aes-128-ctr","cipherparams":{"iv":"6087dab2f9fdbbfaddc31a909735c1e6"},` +
+		`"ciphertext":"5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46","kdf":"pbkdf2",` +
+		`"kdfparams":{"c":262144,"dklen":32,"prf":"hmac-sha256","salt":"ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"},` +
+		`"mac":"517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"},"id":"3198bc9c-6672-5ab3-d995-4942343ae5b6","version":3}`,
+}
+
+const keystoreTestPrivateKey = "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"
+
+func useLightKeystore(t *testing.T) {
+	n, p := KeystoreScryptN, KeystoreScryptP
+	KeystoreScryptN, KeystoreScryptP = keystore.LightScryptN, keystore.LightScryptP
+	t.Cleanup(func() { KeystoreScryptN, KeystoreScryptP = n, p })
+}
+
+func TestKeystore(t *testing.T) {
+	useLightKeystore(t)
+	for kdf, keyJSON := range keystoreTestVectors {
+		key, err := DecryptKeystore([]byte(keyJSON), "testpassword")
+		if err != nil {
+			t.Fatalf("%s: %v", kdf, err)
+		}
+		if hex.EncodeToString(crypto.FromECDSA(key)) != keystoreTestPrivateKey {
+			t.Fatalf("%s: unexpected key %x", kdf, crypto.FromECDSA(key))
+		}
+		if _, err := DecryptKeystore([]byte(keyJSON), "wrong"); err != keystore.ErrDecrypt {
+			t.Fatalf("%s: expected decrypt error, got %v", kdf, err)
+		}
+	}
+
+	// 导出后再导入
+	path := filepath.Join(t.TempDir(), "key.json")
+	key, _ := crypto.HexToECDSA(keystoreTestPrivateKey)
+	auth, _ := NewEAuth()
+	auth.Private = key
+	keyJSON, err := auth.ExportKeystore("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, keyJSON, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewEAuth(WithKeystore(path, "secret"))
+	if err != nil || !loaded.Private.Equal(key) {
+		t.Fatalf("keystore round trip failed %v", err)
+	}
+	if _, err := NewEAuth(WithKeystore(path, "wrong")); !errors.Is(err, keystore.ErrDecrypt) {
+		t.Fatalf("expected decrypt error, got %v", err)
+	}
+}
+
+func TestKeystoreDir(t *testing.T) {
+	useLightKeystore(t)
+	dir := NewKeystoreDir(filepath.Join(t.TempDir(), "keys"))
+	first, _ := crypto.HexToECDSA(keystoreTestPrivateKey)
+	second, _ := crypto.GenerateKey()
+	for _, key := range []*ecdsa.PrivateKey{first, second} {
+		if _, err := dir.Import(key, "secret"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := dir.Import(first, "secret"); err == nil {
+		t.Fatal("expected duplicate import error")
+	}
+	accounts, err := dir.Accounts()
+	if err != nil || len(accounts) != 2 {
+		t.Fatalf("unexpected accounts %v %v", accounts, err)
+	}
+	addr := crypto.PubkeyToAddress(second.PublicKey)
+	if accounts[0] != addr && accounts[1] != addr {
+		t.Fatalf("missing account %s", addr.Hex())
+	}
+	auth, err := dir.Eauth(addr, "secret")
+	if err != nil || !auth.Private.Equal(second) {
+		t.Fatalf("unexpected eauth %v", err)
+	}
+	if _, err := dir.Load(addr, "wrong"); !errors.Is(err, keystore.ErrDecrypt) {
+		t.Fatalf("expected decrypt error, got %v", err)
+	}
+	if _, err := dir.Load(common.HexToAddress("0x01"), "secret"); err == nil {
+		t.Fatal("expected not found error")
+	}
+}
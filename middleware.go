@@ -0,0 +1,330 @@
+package laukit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RpcCall 一次 http 请求中的 JSON-RPC 调用, batch 请求时 Methods 有多个
+type RpcCall struct {
+	Methods []string
+	Body    []byte
+	Header  http.Header
+}
+
+// RpcResult 节点返回的 http 响应
+type RpcResult struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+type RpcInvoker func(ctx context.Context, call *RpcCall) (*RpcResult, error)
+
+// RpcInterceptor 包裹一次 rpc 请求, 调用 next 继续执行后面的中间件
+type RpcInterceptor func(ctx context.Context, call *RpcCall, next RpcInvoker) (*RpcResult, error)
+
+// WithRpcMiddleware 为 http(s) 连接添加中间件, 排在前面的中间件在最外层
+func WithRpcMiddleware(interceptors ...RpcInterceptor) EclOptions {
+	return func(ecl *Ecl) {
+		ecl.interceptors = append(ecl.interceptors, interceptors...)
+	}
+}
+
+// NeverRetryMethods 广播交易的方法, 重试可能导致重复发送, 中间件不会重试
+var NeverRetryMethods = map[string]bool{
+	"eth_sendRawTransaction":   true,
+	"eth_sendTransaction":      true,
+	"personal_sendTransaction": true,
+}
+
+// rpcTransport 把 http 请求转换成 RpcCall 后交给中间件链
+type rpcTransport struct {
+	base    http.RoundTripper
+	invoker RpcInvoker
+}
+
+func newRpcTransport(base http.RoundTripper, interceptors []RpcInterceptor) *rpcTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &rpcTransport{base: base}
+	invoker := RpcInvoker(nil)
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		if next == nil {
+			next = t.send
+		}
+		invoker = func(ctx context.Context, call *RpcCall) (*RpcResult, error) {
+			return interceptor(ctx, call, next)
+		}
+	}
+	if invoker == nil {
+		invoker = t.send
+	}
+	t.invoker = invoker
+	return t
+}
+
+type rpcRequestMessage struct {
+	Method string `json:"method"`
+}
+
+type rpcResponseMessage struct {
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (t *rpcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+	call := &RpcCall{Methods: rpcMethods(body), Body: body, Header: req.Header.Clone()}
+
+	ctx := context.WithValue(req.Context(), rpcRequestKey{}, req)
+	result, err := t.invoker(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", result.StatusCode, http.StatusText(result.StatusCode)),
+		StatusCode:    result.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        result.Header,
+		Body:          io.NopCloser(bytes.NewReader(result.Body)),
+		ContentLength: int64(len(result.Body)),
+		Request:       req,
+	}, nil
+}
+
+type rpcRequestKey struct{}
+
+// rpcMethods 返回请求体中的 JSON-RPC 方法, batch 请求时有多个
+func rpcMethods(body []byte) []string {
+	if len(body) > 0 && body[0] == '[' {
+		var msgs []rpcRequestMessage
+		_ = json.Unmarshal(body, &msgs)
+		methods := make([]string, 0, len(msgs))
+		for _, m := range msgs {
+			methods = append(methods, m.Method)
+		}
+		return methods
+	}
+	var msg rpcRequestMessage
+	_ = json.Unmarshal(body, &msg)
+	return []string{msg.Method}
+}
+
+// isBroadcast 请求中包含 NeverRetryMethods 中的方法
+func isBroadcast(methods []string) bool {
+	for _, method := range methods {
+		if NeverRetryMethods[method] {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *rpcTransport) send(ctx context.Context, call *RpcCall) (*RpcResult, error) {
+	orig, _ := ctx.Value(rpcRequestKey{}).(*http.Request)
+	if orig == nil {
+		return nil, fmt.Errorf("rpc middleware: missing http request")
+	}
+	req := orig.Clone(ctx)
+	req.Header = call.Header
+	req.Body = io.NopCloser(bytes.NewReader(call.Body))
+	req.ContentLength = int64(len(call.Body))
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &RpcResult{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}, nil
+}
+
+// RateLimitInterceptor 令牌桶限流, rate 为每秒请求数, 每个返回值有自己的令牌桶
+func RateLimitInterceptor(rate float64, burst int) RpcInterceptor {
+	if burst < 1 {
+		burst = 1
+	}
+	bucket := &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+	return func(ctx context.Context, call *RpcCall, next RpcInvoker) (*RpcResult, error) {
+		if err := bucket.wait(ctx); err != nil {
+			return nil, err
+		}
+		return next(ctx, call)
+	}
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+type RetryConfig struct {
+	// MaxAttempts 最多请求次数, 包含第一次
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Retryable 不为空时替换默认的判断
+	Retryable func(result *RpcResult, err error) bool
+}
+
+// RetryInterceptor 对限流、节点暂时不可用等错误按指数退避重试, 广播交易的请求不会重试
+func RetryInterceptor(cfg RetryConfig) RpcInterceptor {
+	if cfg.MaxAttempts < 1 {
+		cfg.MaxAttempts = 3
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = 200 * time.Millisecond
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = 5 * time.Second
+	}
+	if cfg.Retryable == nil {
+		cfg.Retryable = IsRetryableRpcResult
+	}
+	return func(ctx context.Context, call *RpcCall, next RpcInvoker) (*RpcResult, error) {
+		if isBroadcast(call.Methods) {
+			return next(ctx, call)
+		}
+		delay := cfg.BaseDelay
+		for attempt := 1; ; attempt++ {
+			result, err := next(ctx, call)
+			if attempt >= cfg.MaxAttempts || ctx.Err() != nil || !cfg.Retryable(result, err) {
+				return result, err
+			}
+			// 加上最多 20% 的随机抖动
+			wait := delay + time.Duration(rand.Int63n(int64(delay)/5+1))
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return result, err
+			case <-timer.C:
+			}
+			delay *= 2
+			if delay > cfg.MaxDelay {
+				delay = cfg.MaxDelay
+			}
+		}
+	}
+}
+
+// IsRetryableRpcResult 判断请求是否可以重试: 网络错误, http 429/502/503/504,
+// JSON-RPC 错误码 429 / -32005 以及 header not found 等节点暂时性错误
+func IsRetryableRpcResult(result *RpcResult, err error) bool {
+	if err != nil {
+		return true
+	}
+	if result == nil {
+		return false
+	}
+	switch result.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	for _, msg := range rpcResponseMessages(result.Body) {
+		if msg.Error == nil {
+			continue
+		}
+		if msg.Error.Code == 429 || msg.Error.Code == -32005 {
+			return true
+		}
+		text := strings.ToLower(msg.Error.Message)
+		for _, s := range retryableRpcMessages {
+			if strings.Contains(text, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func rpcResponseMessages(body []byte) []rpcResponseMessage {
+	var msgs []rpcResponseMessage
+	if len(body) > 0 && body[0] == '[' {
+		_ = json.Unmarshal(body, &msgs)
+	} else {
+		var msg rpcResponseMessage
+		_ = json.Unmarshal(body, &msg)
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+var retryableRpcMessages = []string{"header not found", "rate limit", "too many requests", "request limit"}
+
+// TimeoutInterceptor 按方法设置超时, 没有单独配置的方法使用 def, batch 请求取其中最长的超时
+func TimeoutInterceptor(def time.Duration, perMethod map[string]time.Duration) RpcInterceptor {
+	return func(ctx context.Context, call *RpcCall, next RpcInvoker) (*RpcResult, error) {
+		var timeout time.Duration
+		for _, method := range call.Methods {
+			t, ok := perMethod[method]
+			if !ok {
+				t = def
+			}
+			if t > timeout {
+				timeout = t
+			}
+		}
+		if timeout <= 0 {
+			return next(ctx, call)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return next(ctx, call)
+	}
+}
@@ -0,0 +1,82 @@
+package laukit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestRpcMiddleware(t *testing.T) {
+	failures := 2
+	stub := newStubRPC(t, map[string]stubHandler{
+		"eth_blockNumber": func([]json.RawMessage) (interface{}, error) {
+			if failures > 0 {
+				failures--
+				return nil, &stubError{Code: -32005, Message: "limit exceeded"}
+			}
+			return "0x64", nil
+		},
+		"eth_sendRawTransaction": func([]json.RawMessage) (interface{}, error) {
+			return nil, &stubError{Code: 429, Message: "too many requests"}
+		},
+		"eth_getBalance": func([]json.RawMessage) (interface{}, error) {
+			time.Sleep(200 * time.Millisecond)
+			return "0x1", nil
+		},
+	})
+
+	var methods []string
+	ecl := stub.ecl(t, WithRpcMiddleware(
+		func(ctx context.Context, call *RpcCall, next RpcInvoker) (*RpcResult, error) {
+			methods = append(methods, call.Methods...)
+			return next(ctx, call)
+		},
+		TimeoutInterceptor(time.Second, map[string]time.Duration{"eth_getBalance": 50 * time.Millisecond}),
+		RetryInterceptor(RetryConfig{MaxAttempts: 5, BaseDelay: time.Millisecond}),
+		RateLimitInterceptor(1000, 10),
+	))
+
+	n, err := ecl.BlockNumber(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 100 || stub.count("eth_blockNumber") != 3 {
+		t.Fatalf("unexpected block number %d after %d calls", n, stub.count("eth_blockNumber"))
+	}
+
+	key, _ := crypto.GenerateKey()
+	to := common.HexToAddress("0x487ee5d805b3c95eb23055dc92aad29a89961f17")
+	tx := types.MustSignNewTx(key, types.LatestSignerForChainID(ecl.ChainId), &types.LegacyTx{To: &to, Gas: 21000, GasPrice: big.NewInt(1)})
+	if err := ecl.SendTransaction(context.Background(), tx); err == nil {
+		t.Fatal("expected send error")
+	}
+	if stub.count("eth_sendRawTransaction") != 1 {
+		t.Fatalf("broadcast retried %d times", stub.count("eth_sendRawTransaction"))
+	}
+
+	if _, err := ecl.BalanceAt(context.Background(), to, nil); err == nil {
+		t.Fatal("expected per-method timeout")
+	}
+	if len(methods) != 4 || methods[0] != "eth_chainId" {
+		t.Fatalf("unexpected intercepted methods %v", methods)
+	}
+}
+
+func TestRateLimitInterceptor(t *testing.T) {
+	limit := RateLimitInterceptor(100, 1)
+	next := func(ctx context.Context, call *RpcCall) (*RpcResult, error) { return &RpcResult{}, nil }
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if _, err := limit(context.Background(), &RpcCall{}, next); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("rate limit not applied, took %v", elapsed)
+	}
+}
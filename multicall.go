@@ -0,0 +1,148 @@
+package laukit
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"math/big"
+)
+
+// Multicall3Address 各条链上 Multicall3 的默认部署地址
+var Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// MulticallAddresses 按 chainId 覆盖 Multicall3 地址
+var MulticallAddresses = map[uint64]common.Address{}
+
+// DefaultMulticallChunkSize 单次 aggregate3 默认最多包含的调用数
+var DefaultMulticallChunkSize = 200
+
+const multicall3ABI = `[{"inputs":[{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bool","name":"allowFailure","type":"bool"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall3.Call3[]","name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall3.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`
+
+var multicall3 = MustParseABI(multicall3ABI)
+
+type multicall3Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type multicall3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// MulticallCall 一个只读合约调用
+type MulticallCall struct {
+	Target common.Address
+	ABI    abi.ABI
+	Method string
+	Args   []interface{}
+	// AllowFailure 为 false 时该调用失败会导致整个 aggregate3 revert
+	AllowFailure bool
+}
+
+// MulticallResult 调用结果, Values 按方法的 outputs 解析
+type MulticallResult struct {
+	Success    bool
+	Values     []interface{}
+	ReturnData []byte
+	Err        error
+}
+
+type Multicall struct {
+	ecl     *Ecl
+	Address common.Address
+	// ChunkSize 超过该数量的调用拆分成多次 aggregate3
+	ChunkSize int
+	// BlockNumber 为空时查询最新区块
+	BlockNumber *big.Int
+}
+
+// NewMulticall 按 ecl 的 chainId 选择 Multicall3 地址, 没有配置时使用默认地址
+func NewMulticall(ecl *Ecl) *Multicall {
+	m := &Multicall{ecl: ecl, Address: Multicall3Address, ChunkSize: DefaultMulticallChunkSize}
+	if ecl != nil && ecl.ChainId != nil {
+		if addr, ok := MulticallAddresses[ecl.ChainId.Uint64()]; ok {
+			m.Address = addr
+		}
+	}
+	return m
+}
+
+// Call 把 calls 打包成 aggregate3 调用, 返回结果与 calls 一一对应
+func (m *Multicall) Call(ctx context.Context, calls []MulticallCall) ([]MulticallResult, error) {
+	if m.ecl == nil {
+		return nil, fmt.Errorf("%s ecl client is nil", errorPath)
+	}
+	packed := make([]multicall3Call, len(calls))
+	for i, call := range calls {
+		data, err := EncodeInputData(call.ABI, call.Method, call.Args...)
+		if err != nil {
+			return nil, fmt.Errorf("multicall encode call %d %s error: %w", i, call.Method, err)
+		}
+		packed[i] = multicall3Call{Target: call.Target, AllowFailure: call.AllowFailure, CallData: data}
+	}
+	chunkSize := m.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultMulticallChunkSize
+	}
+
+	results := make([]MulticallResult, 0, len(calls))
+	for start := 0; start < len(packed); start += chunkSize {
+		end := start + chunkSize
+		if end > len(packed) {
+			end = len(packed)
+		}
+		chunk, err := m.aggregate3(ctx, packed[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for i, r := range chunk {
+			results = append(results, decodeMulticallResult(calls[start+i], r))
+		}
+	}
+	return results, nil
+}
+
+func (m *Multicall) aggregate3(ctx context.Context, calls []multicall3Call) ([]multicall3Result, error) {
+	input, err := EncodeInputData(multicall3, "aggregate3", calls)
+	if err != nil {
+		return nil, fmt.Errorf("multicall encode aggregate3 error: %w", err)
+	}
+	output, err := m.ecl.CallContract(ctx, ethereum.CallMsg{To: &m.Address, Data: input}, m.BlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("multicall aggregate3 error: %w", DecodeCallError(err))
+	}
+	values, err := multicall3.Methods["aggregate3"].Outputs.UnpackValues(output)
+	if err != nil {
+		return nil, fmt.Errorf("multicall decode aggregate3 error: %w", err)
+	}
+	results := *abi.ConvertType(values[0], new([]multicall3Result)).(*[]multicall3Result)
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("multicall aggregate3 returned %d results for %d calls", len(results), len(calls))
+	}
+	return results, nil
+}
+
+func decodeMulticallResult(call MulticallCall, r multicall3Result) MulticallResult {
+	result := MulticallResult{Success: r.Success, ReturnData: r.ReturnData}
+	if !r.Success {
+		revert, err := DecodeRevert(r.ReturnData, call.ABI)
+		if err != nil {
+			result.Err = err
+		} else {
+			result.Err = revert
+		}
+		return result
+	}
+	values, err := call.ABI.Methods[call.Method].Outputs.UnpackValues(r.ReturnData)
+	if err != nil {
+		result.Success = false
+		result.Err = fmt.Errorf("multicall decode %s result error: %w", call.Method, err)
+		return result
+	}
+	result.Values = values
+	return result
+}
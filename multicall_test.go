@@ -0,0 +1,75 @@
+package laukit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"math/big"
+	"testing"
+)
+
+const multicallTestABI = `[{"inputs":[{"internalType":"address","name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"internalType":"uint256","name":"result","type":"uint256"}],"stateMutability":"view","type":"function"}]`
+
+func TestMulticall(t *testing.T) {
+	var aggregateCalls int
+	stub := newStubRPC(t, map[string]stubHandler{
+		"eth_call": func(params []json.RawMessage) (interface{}, error) {
+			aggregateCalls++
+			var msg struct {
+				To   common.Address `json:"to"`
+				Data hexutil.Bytes  `json:"data"`
+			}
+			_ = json.Unmarshal(params[0], &msg)
+			if msg.To != Multicall3Address {
+				return nil, &stubError{Code: -32000, Message: "unexpected target"}
+			}
+			values, err := multicall3.Methods["aggregate3"].Inputs.UnpackValues(msg.Data[4:])
+			if err != nil {
+				return nil, err
+			}
+			calls := *abi.ConvertType(values[0], new([]multicall3Call)).(*[]multicall3Call)
+			results := make([]multicall3Result, len(calls))
+			for i, c := range calls {
+				if c.Target == (common.Address{}) {
+					results[i] = multicall3Result{ReturnData: []byte{}}
+					continue
+				}
+				owner := common.BytesToAddress(c.CallData[4:])
+				out, _ := AbiCoder([]string{"uint256"}, []interface{}{new(big.Int).SetBytes(owner[18:])})
+				results[i] = multicall3Result{Success: true, ReturnData: out}
+			}
+			out, err := multicall3.Methods["aggregate3"].Outputs.Pack(results)
+			if err != nil {
+				return nil, err
+			}
+			return hexutil.Bytes(out), nil
+		},
+	})
+	token := MustParseABI(multicallTestABI)
+	mc := NewMulticall(stub.ecl(t))
+	mc.ChunkSize = 2
+
+	calls := []MulticallCall{
+		{Target: common.HexToAddress("0x01"), ABI: token, Method: "balanceOf", Args: []interface{}{common.HexToAddress("0x0101")}},
+		{Target: common.Address{}, ABI: token, Method: "balanceOf", Args: []interface{}{common.HexToAddress("0x02")}, AllowFailure: true},
+		{Target: common.HexToAddress("0x01"), ABI: token, Method: "balanceOf", Args: []interface{}{common.HexToAddress("0x0203")}},
+	}
+	results, err := mc.Call(context.Background(), calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aggregateCalls != 2 || len(results) != 3 {
+		t.Fatalf("unexpected aggregate calls %d results %d", aggregateCalls, len(results))
+	}
+	if !results[0].Success || results[0].Values[0].(*big.Int).Int64() != 0x0101 {
+		t.Fatalf("unexpected result %+v", results[0])
+	}
+	if results[1].Success || results[1].Err == nil {
+		t.Fatalf("expected failure %+v", results[1])
+	}
+	if results[2].Values[0].(*big.Int).Int64() != 0x0203 {
+		t.Fatalf("unexpected result %+v", results[2])
+	}
+}
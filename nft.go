@@ -0,0 +1,291 @@
+package laukit
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+	"strings"
+)
+
+// ERC165 接口 ID
+var (
+	InterfaceIdERC165             = [4]byte{0x01, 0xff, 0xc9, 0xa7}
+	InterfaceIdERC721             = [4]byte{0x80, 0xac, 0x58, 0xcd}
+	InterfaceIdERC721Metadata     = [4]byte{0x5b, 0x5e, 0x13, 0x9f}
+	InterfaceIdERC721Enumerable   = [4]byte{0x78, 0x0e, 0x9d, 0x63}
+	InterfaceIdERC1155            = [4]byte{0xd9, 0xb6, 0x7a, 0x26}
+	InterfaceIdERC1155MetadataURI = [4]byte{0x0e, 0x89, 0x34, 0x1c}
+)
+
+var erc165ABI = MustParseHumanABI("function supportsInterface(bytes4 interfaceId) view returns (bool)")
+
+var erc721ABI = MustParseHumanABI(
+	"function supportsInterface(bytes4 interfaceId) view returns (bool)",
+	"function balanceOf(address owner) view returns (uint256)",
+	"function ownerOf(uint256 tokenId) view returns (address)",
+	"function tokenURI(uint256 tokenId) view returns (string)",
+	"function isApprovedForAll(address owner, address operator) view returns (bool)",
+	"function safeTransferFrom(address from, address to, uint256 tokenId)",
+	"function safeTransferFrom(address from, address to, uint256 tokenId, bytes data)",
+	"function setApprovalForAll(address operator, bool approved)",
+	"event Transfer(address indexed from, address indexed to, uint256 indexed tokenId)",
+)
+
+var erc1155ABI = MustParseHumanABI(
+	"function supportsInterface(bytes4 interfaceId) view returns (bool)",
+	"function balanceOf(address account, uint256 id) view returns (uint256)",
+	"function balanceOfBatch(address[] accounts, uint256[] ids) view returns (uint256[])",
+	"function uri(uint256 id) view returns (string)",
+	"function isApprovedForAll(address account, address operator) view returns (bool)",
+	"function safeTransferFrom(address from, address to, uint256 id, uint256 amount, bytes data)",
+	"function safeBatchTransferFrom(address from, address to, uint256[] ids, uint256[] amounts, bytes data)",
+	"function setApprovalForAll(address operator, bool approved)",
+	"event TransferSingle(address indexed operator, address indexed from, address indexed to, uint256 id, uint256 value)",
+	"event TransferBatch(address indexed operator, address indexed from, address indexed to, uint256[] ids, uint256[] values)",
+)
+
+// EclSupportsInterface 按 ERC165 规范检测合约是否实现了 interfaceId
+// 合约不支持 ERC165 或调用 revert 时返回 false, 限流等节点错误原样返回
+func EclSupportsInterface(ctx context.Context, ecl *Ecl, address common.Address, interfaceId [4]byte) (bool, error) {
+	supports := func(id [4]byte) (bool, error) {
+		input, err := EncodeInputData(erc165ABI, "supportsInterface", id)
+		if err != nil {
+			return false, err
+		}
+		// 规范要求检测时最多使用 30000 gas
+		output, err := ecl.CallContract(ctx, ethereum.CallMsg{To: &address, Gas: 30000, Data: input}, nil)
+		if err != nil {
+			if IsRevertError(err) {
+				return false, nil
+			}
+			return false, fmt.Errorf("erc165 %s supportsInterface error: %w", address.Hex(), err)
+		}
+		values, err := erc165ABI.Methods["supportsInterface"].Outputs.UnpackValues(output)
+		if err != nil {
+			return false, nil
+		}
+		return values[0].(bool), nil
+	}
+	if ok, err := supports(InterfaceIdERC165); err != nil || !ok {
+		return false, err
+	}
+	if ok, err := supports([4]byte{0xff, 0xff, 0xff, 0xff}); err != nil || ok {
+		return false, err
+	}
+	if interfaceId == InterfaceIdERC165 {
+		return true, nil
+	}
+	return supports(interfaceId)
+}
+
+// ERC721Transfer Transfer 事件
+type ERC721Transfer struct {
+	From    common.Address
+	To      common.Address
+	TokenId *big.Int
+	Raw     types.Log
+}
+
+// ERC1155TransferSingle TransferSingle 事件
+type ERC1155TransferSingle struct {
+	Operator common.Address
+	From     common.Address
+	To       common.Address
+	Id       *big.Int
+	Value    *big.Int
+	Raw      types.Log
+}
+
+// ERC1155TransferBatch TransferBatch 事件, Ids 与 Values 一一对应
+type ERC1155TransferBatch struct {
+	Operator common.Address
+	From     common.Address
+	To       common.Address
+	Ids      []*big.Int
+	Values   []*big.Int
+	Raw      types.Log
+}
+
+// ParseERC721Transfer 解析 ERC721 的 Transfer 日志, ERC20 的 Transfer (tokenId 不是 indexed) 会返回错误
+func ParseERC721Transfer(log types.Log) (*ERC721Transfer, error) {
+	event := &ERC721Transfer{Raw: log}
+	if err := parseNFTLog(erc721ABI, "Transfer", log, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func ParseERC1155TransferSingle(log types.Log) (*ERC1155TransferSingle, error) {
+	event := &ERC1155TransferSingle{Raw: log}
+	if err := parseNFTLog(erc1155ABI, "TransferSingle", log, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func ParseERC1155TransferBatch(log types.Log) (*ERC1155TransferBatch, error) {
+	event := &ERC1155TransferBatch{Raw: log}
+	if err := parseNFTLog(erc1155ABI, "TransferBatch", log, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+func parseNFTLog(contractABI abi.ABI, name string, log types.Log, out interface{}) error {
+	if len(log.Topics) == 0 || log.Topics[0] != contractABI.Events[name].ID {
+		return fmt.Errorf("log is not a %s event", name)
+	}
+	_, err := DecodeLogInto(contractABI, log, out)
+	return err
+}
+
+// ERC721 绑定到一个 NFT 合约地址的客户端
+type ERC721 struct {
+	ecl     *Ecl
+	Address common.Address
+}
+
+func NewERC721(ecl *Ecl, address common.Address) *ERC721 {
+	return &ERC721{ecl: ecl, Address: address}
+}
+
+func (n *ERC721) SupportsInterface(ctx context.Context, interfaceId [4]byte) (bool, error) {
+	return EclSupportsInterface(ctx, n.ecl, n.Address, interfaceId)
+}
+
+func (n *ERC721) BalanceOf(ctx context.Context, owner common.Address) (*big.Int, error) {
+	values, err := n.call(ctx, "balanceOf", owner)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*big.Int), nil
+}
+
+func (n *ERC721) OwnerOf(ctx context.Context, tokenId *big.Int) (common.Address, error) {
+	values, err := n.call(ctx, "ownerOf", tokenId)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return values[0].(common.Address), nil
+}
+
+func (n *ERC721) TokenURI(ctx context.Context, tokenId *big.Int) (string, error) {
+	values, err := n.call(ctx, "tokenURI", tokenId)
+	if err != nil {
+		return "", err
+	}
+	return values[0].(string), nil
+}
+
+func (n *ERC721) IsApprovedForAll(ctx context.Context, owner, operator common.Address) (bool, error) {
+	values, err := n.call(ctx, "isApprovedForAll", owner, operator)
+	if err != nil {
+		return false, err
+	}
+	return values[0].(bool), nil
+}
+
+// SafeTransferFrom data 为空时调用不带 data 的重载
+func (n *ERC721) SafeTransferFrom(ctx context.Context, auth *Eauth, from, to common.Address, tokenId *big.Int, data []byte) (*types.Transaction, WaitReceipt, error) {
+	if data == nil {
+		return EclSendContractTransaction(ctx, n.ecl, auth, n.Address, nil, erc721ABI, "safeTransferFrom", from, to, tokenId)
+	}
+	return EclSendContractTransaction(ctx, n.ecl, auth, n.Address, nil, erc721ABI, "safeTransferFrom0", from, to, tokenId, data)
+}
+
+func (n *ERC721) SetApprovalForAll(ctx context.Context, auth *Eauth, operator common.Address, approved bool) (*types.Transaction, WaitReceipt, error) {
+	return EclSendContractTransaction(ctx, n.ecl, auth, n.Address, nil, erc721ABI, "setApprovalForAll", operator, approved)
+}
+
+func (n *ERC721) call(ctx context.Context, method string, args ...interface{}) ([]interface{}, error) {
+	values, err := EclCallContract(ctx, n.ecl, n.Address, erc721ABI, method, nil, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erc721 %s: %w", n.Address.Hex(), err)
+	}
+	return values, nil
+}
+
+// ERC1155 绑定到一个多代币合约地址的客户端
+type ERC1155 struct {
+	ecl     *Ecl
+	Address common.Address
+}
+
+func NewERC1155(ecl *Ecl, address common.Address) *ERC1155 {
+	return &ERC1155{ecl: ecl, Address: address}
+}
+
+func (n *ERC1155) SupportsInterface(ctx context.Context, interfaceId [4]byte) (bool, error) {
+	return EclSupportsInterface(ctx, n.ecl, n.Address, interfaceId)
+}
+
+func (n *ERC1155) BalanceOf(ctx context.Context, account common.Address, id *big.Int) (*big.Int, error) {
+	values, err := n.call(ctx, "balanceOf", account, id)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].(*big.Int), nil
+}
+
+// BalanceOfBatch accounts 与 ids 一一对应
+func (n *ERC1155) BalanceOfBatch(ctx context.Context, accounts []common.Address, ids []*big.Int) ([]*big.Int, error) {
+	if len(accounts) != len(ids) {
+		return nil, fmt.Errorf("erc1155 %s: accounts and ids length mismatch", n.Address.Hex())
+	}
+	values, err := n.call(ctx, "balanceOfBatch", accounts, ids)
+	if err != nil {
+		return nil, err
+	}
+	return values[0].([]*big.Int), nil
+}
+
+// URI 返回 id 的元数据地址, 按规范把 {id} 替换为 64 位小写 hex
+func (n *ERC1155) URI(ctx context.Context, id *big.Int) (string, error) {
+	values, err := n.call(ctx, "uri", id)
+	if err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(values[0].(string), "{id}", fmt.Sprintf("%064x", id)), nil
+}
+
+func (n *ERC1155) IsApprovedForAll(ctx context.Context, account, operator common.Address) (bool, error) {
+	values, err := n.call(ctx, "isApprovedForAll", account, operator)
+	if err != nil {
+		return false, err
+	}
+	return values[0].(bool), nil
+}
+
+func (n *ERC1155) SafeTransferFrom(ctx context.Context, auth *Eauth, from, to common.Address, id, amount *big.Int, data []byte) (*types.Transaction, WaitReceipt, error) {
+	if data == nil {
+		data = []byte{}
+	}
+	return EclSendContractTransaction(ctx, n.ecl, auth, n.Address, nil, erc1155ABI, "safeTransferFrom", from, to, id, amount, data)
+}
+
+// SafeBatchTransferFrom ids 与 amounts 一一对应
+func (n *ERC1155) SafeBatchTransferFrom(ctx context.Context, auth *Eauth, from, to common.Address, ids, amounts []*big.Int, data []byte) (*types.Transaction, WaitReceipt, error) {
+	if len(ids) != len(amounts) {
+		return nil, nil, fmt.Errorf("erc1155 %s: ids and amounts length mismatch", n.Address.Hex())
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return EclSendContractTransaction(ctx, n.ecl, auth, n.Address, nil, erc1155ABI, "safeBatchTransferFrom", from, to, ids, amounts, data)
+}
+
+func (n *ERC1155) SetApprovalForAll(ctx context.Context, auth *Eauth, operator common.Address, approved bool) (*types.Transaction, WaitReceipt, error) {
+	return EclSendContractTransaction(ctx, n.ecl, auth, n.Address, nil, erc1155ABI, "setApprovalForAll", operator, approved)
+}
+
+func (n *ERC1155) call(ctx context.Context, method string, args ...interface{}) ([]interface{}, error) {
+	values, err := EclCallContract(ctx, n.ecl, n.Address, erc1155ABI, method, nil, args...)
+	if err != nil {
+		return nil, fmt.Errorf("erc1155 %s: %w", n.Address.Hex(), err)
+	}
+	return values, nil
+}
@@ -0,0 +1,190 @@
+package laukit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"testing"
+)
+
+func TestEclSupportsInterface(t *testing.T) {
+	ctx := context.Background()
+	nft := common.HexToAddress("0xaa")
+	stub := newStubRPC(t, map[string]stubHandler{
+		"eth_call": stubContract(t, erc165ABI, map[string]stubContractMethod{
+			"supportsInterface": func(to common.Address, args []interface{}) ([]interface{}, error) {
+				if to != nft {
+					return nil, &stubError{Code: 3, Message: "execution reverted"}
+				}
+				id := args[0].([4]byte)
+				return []interface{}{id == InterfaceIdERC165 || id == InterfaceIdERC721}, nil
+			},
+		}),
+	})
+	ecl := stub.ecl(t)
+	for id, want := range map[[4]byte]bool{InterfaceIdERC165: true, InterfaceIdERC721: true, InterfaceIdERC1155: false} {
+		if ok, err := EclSupportsInterface(ctx, ecl, nft, id); err != nil || ok != want {
+			t.Fatalf("supportsInterface(%x) = %v %v, want %v", id, ok, err, want)
+		}
+	}
+	if ok, err := EclSupportsInterface(ctx, ecl, common.HexToAddress("0xbb"), InterfaceIdERC721); err != nil || ok {
+		t.Fatalf("non erc165 contract: %v %v", ok, err)
+	}
+	// 限流不能当作不支持
+	stub.handle("eth_call", func([]json.RawMessage) (interface{}, error) {
+		return nil, &stubError{Code: -32005, Message: "request rate limited"}
+	})
+	if _, err := EclSupportsInterface(ctx, ecl, nft, InterfaceIdERC721); err == nil {
+		t.Fatal("expected rate limit error")
+	}
+}
+
+func TestERC721(t *testing.T) {
+	ctx := context.Background()
+	owner := common.HexToAddress("0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17")
+	to := common.HexToAddress("0x487ee5d805b3c95eb23055dc92aad29a89961f17")
+	var sent []*types.Transaction
+	stub := newStubRPC(t, map[string]stubHandler{
+		"eth_call": stubContract(t, erc721ABI, map[string]stubContractMethod{
+			"ownerOf": func(_ common.Address, args []interface{}) ([]interface{}, error) {
+				if args[0].(*big.Int).Int64() != 7 {
+					return nil, &stubError{Code: 3, Message: "execution reverted"}
+				}
+				return []interface{}{owner}, nil
+			},
+			"tokenURI": func(common.Address, []interface{}) ([]interface{}, error) {
+				return []interface{}{"ipfs://token/7"}, nil
+			},
+		}),
+		"eth_getTransactionCount": func([]json.RawMessage) (interface{}, error) { return "0x0", nil },
+		"eth_gasPrice":            func([]json.RawMessage) (interface{}, error) { return "0x1", nil },
+		"eth_estimateGas":         func([]json.RawMessage) (interface{}, error) { return "0x186a0", nil },
+		"eth_sendRawTransaction": func(params []json.RawMessage) (interface{}, error) {
+			var raw hexutil.Bytes
+			_ = json.Unmarshal(params[0], &raw)
+			tx := new(types.Transaction)
+			if err := tx.UnmarshalBinary(raw); err != nil {
+				return nil, err
+			}
+			sent = append(sent, tx)
+			return tx.Hash(), nil
+		},
+	})
+	nft := NewERC721(stub.ecl(t), common.HexToAddress("0xaa"))
+	if got, err := nft.OwnerOf(ctx, big.NewInt(7)); err != nil || got != owner {
+		t.Fatalf("unexpected owner %s %v", got.Hex(), err)
+	}
+	if _, err := nft.OwnerOf(ctx, big.NewInt(8)); err == nil {
+		t.Fatal("expected revert for unknown token")
+	}
+	if uri, err := nft.TokenURI(ctx, big.NewInt(7)); err != nil || uri != "ipfs://token/7" {
+		t.Fatalf("unexpected token uri %q %v", uri, err)
+	}
+
+	key, _ := crypto.ToECDSA(crypto.Keccak256([]byte("cow")))
+	auth, _ := NewEAuth(WithEcl(nft.ecl))
+	auth.Private = key
+	if _, _, err := nft.SafeTransferFrom(ctx, auth, owner, to, big.NewInt(7), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := nft.SafeTransferFrom(ctx, auth, owner, to, big.NewInt(7), []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 {
+		t.Fatalf("unexpected sent transactions %d", len(sent))
+	}
+	if hexutil.Encode(sent[0].Data()[:4]) != FunctionSignature("safeTransferFrom(address,address,uint256)") ||
+		hexutil.Encode(sent[1].Data()[:4]) != FunctionSignature("safeTransferFrom(address,address,uint256,bytes)") {
+		t.Fatalf("unexpected selectors %x %x", sent[0].Data()[:4], sent[1].Data()[:4])
+	}
+}
+
+func TestERC1155(t *testing.T) {
+	ctx := context.Background()
+	stub := newStubRPC(t, map[string]stubHandler{
+		"eth_call": stubContract(t, erc1155ABI, map[string]stubContractMethod{
+			"uri": func(common.Address, []interface{}) ([]interface{}, error) {
+				return []interface{}{"https://token-cdn-domain/{id}.json"}, nil
+			},
+			"balanceOfBatch": func(_ common.Address, args []interface{}) ([]interface{}, error) {
+				ids := args[1].([]*big.Int)
+				balances := make([]*big.Int, len(ids))
+				for i, id := range ids {
+					balances[i] = new(big.Int).Mul(id, big.NewInt(10))
+				}
+				return []interface{}{balances}, nil
+			},
+		}),
+	})
+	nft := NewERC1155(stub.ecl(t), common.HexToAddress("0xaa"))
+	uri, err := nft.URI(ctx, big.NewInt(314592))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri != "https://token-cdn-domain/000000000000000000000000000000000000000000000000000000000004cce0.json" {
+		t.Fatalf("unexpected uri %s", uri)
+	}
+	owner := common.HexToAddress("0x01")
+	balances, err := nft.BalanceOfBatch(ctx, []common.Address{owner, owner}, []*big.Int{big.NewInt(1), big.NewInt(2)})
+	if err != nil || len(balances) != 2 || balances[1].Int64() != 20 {
+		t.Fatalf("unexpected balances %v %v", balances, err)
+	}
+	if _, err := nft.BalanceOfBatch(ctx, []common.Address{owner}, nil); err == nil {
+		t.Fatal("expected length mismatch error")
+	}
+}
+
+func TestParseNFTLogs(t *testing.T) {
+	operator := common.HexToAddress("0x01")
+	from := common.HexToAddress("0x02")
+	to := common.HexToAddress("0x03")
+	topic := func(a common.Address) common.Hash { return common.BytesToHash(a.Bytes()) }
+
+	transfer, err := ParseERC721Transfer(types.Log{
+		Topics: []common.Hash{erc721ABI.Events["Transfer"].ID, topic(from), topic(to), common.BigToHash(big.NewInt(7))},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transfer.From != from || transfer.To != to || transfer.TokenId.Int64() != 7 {
+		t.Fatalf("unexpected transfer %+v", transfer)
+	}
+	// ERC20 的 Transfer 只有 3 个 topic
+	amount, _ := AbiCoder([]string{"uint256"}, []interface{}{big.NewInt(7)})
+	if _, err := ParseERC721Transfer(types.Log{Topics: []common.Hash{erc721ABI.Events["Transfer"].ID, topic(from), topic(to)}, Data: amount}); err == nil {
+		t.Fatal("expected error for erc20 transfer log")
+	}
+
+	data, _ := AbiCoder([]string{"uint256", "uint256"}, []interface{}{big.NewInt(5), big.NewInt(2)})
+	single, err := ParseERC1155TransferSingle(types.Log{
+		Topics: []common.Hash{erc1155ABI.Events["TransferSingle"].ID, topic(operator), topic(from), topic(to)},
+		Data:   data,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if single.Operator != operator || single.Id.Int64() != 5 || single.Value.Int64() != 2 {
+		t.Fatalf("unexpected transfer single %+v", single)
+	}
+
+	data, _ = AbiCoder([]string{"uint256[]", "uint256[]"}, []interface{}{
+		[]*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{big.NewInt(10), big.NewInt(20)},
+	})
+	batch, err := ParseERC1155TransferBatch(types.Log{
+		Topics: []common.Hash{erc1155ABI.Events["TransferBatch"].ID, topic(operator), topic(from), topic(to)},
+		Data:   data,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batch.Ids) != 2 || batch.Ids[1].Int64() != 2 || batch.Values[1].Int64() != 20 || batch.To != to {
+		t.Fatalf("unexpected transfer batch %+v", batch)
+	}
+	if _, err := ParseERC1155TransferSingle(batch.Raw); err == nil {
+		t.Fatal("expected event mismatch error")
+	}
+}
@@ -0,0 +1,123 @@
+package laukit
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// NonceManager 按 chainId + 地址在本地分配 nonce, 同一地址的并发发送者不会拿到重复的 nonce
+// 第一次使用某个地址时会从节点同步 pending nonce, 遇到 nonce too low 时重新同步
+type NonceManager struct {
+	mu     sync.Mutex
+	states map[nonceKey]*nonceState
+}
+
+type nonceKey struct {
+	chainId string
+	from    common.Address
+}
+
+type nonceState struct {
+	mu       sync.Mutex
+	synced   bool
+	next     uint64
+	released []uint64 // 已分配但没有广播出去的 nonce, 升序
+}
+
+func NewNonceManager() *NonceManager {
+	return &NonceManager{states: make(map[nonceKey]*nonceState)}
+}
+
+func (m *NonceManager) state(ecl *Ecl, from common.Address) *nonceState {
+	key := nonceKey{from: from}
+	if ecl.ChainId != nil {
+		key.chainId = ecl.ChainId.String()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.states[key]
+	if !ok {
+		s = &nonceState{}
+		m.states[key] = s
+	}
+	return s
+}
+
+// Next 分配下一个可用的 nonce, 优先复用被 Release 的 nonce
+func (m *NonceManager) Next(ctx context.Context, ecl *Ecl, from common.Address) (uint64, error) {
+	if ecl == nil {
+		return 0, fmt.Errorf("%s nonce manager: ecl client is nil", errorPath)
+	}
+	s := m.state(ecl, from)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.synced {
+		if err := s.sync(ctx, ecl, from); err != nil {
+			return 0, err
+		}
+	}
+	if len(s.released) > 0 {
+		nonce := s.released[0]
+		s.released = s.released[1:]
+		return nonce, nil
+	}
+	nonce := s.next
+	s.next++
+	return nonce, nil
+}
+
+// Release 归还一个已分配但从未广播的 nonce, 下一次 Next 会优先使用它
+func (m *NonceManager) Release(ecl *Ecl, from common.Address, nonce uint64) {
+	s := m.state(ecl, from)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.synced || nonce >= s.next {
+		return
+	}
+	i := sort.Search(len(s.released), func(i int) bool { return s.released[i] >= nonce })
+	if i < len(s.released) && s.released[i] == nonce {
+		return
+	}
+	s.released = append(s.released, 0)
+	copy(s.released[i+1:], s.released[i:])
+	s.released[i] = nonce
+	// 归还的是最高位的 nonce 时直接回退 next
+	for len(s.released) > 0 && s.released[len(s.released)-1] == s.next-1 {
+		s.released = s.released[:len(s.released)-1]
+		s.next--
+	}
+}
+
+// Resync 丢弃本地状态, 以节点的 pending nonce 为准
+func (m *NonceManager) Resync(ctx context.Context, ecl *Ecl, from common.Address) error {
+	if ecl == nil {
+		return fmt.Errorf("%s nonce manager: ecl client is nil", errorPath)
+	}
+	s := m.state(ecl, from)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sync(ctx, ecl, from)
+}
+
+func (s *nonceState) sync(ctx context.Context, ecl *Ecl, from common.Address) error {
+	nonce, err := ecl.PendingNonceAt(ctx, from)
+	if err != nil {
+		return fmt.Errorf("%s ecl get pending nonce error %w", errorPath, err)
+	}
+	s.next = nonce
+	s.released = nil
+	s.synced = true
+	return nil
+}
+
+// IsNonceTooLow 判断节点返回的错误是否为 nonce too low
+func IsNonceTooLow(err error) bool {
+	if err == nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "nonce too low")
+}
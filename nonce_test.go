@@ -0,0 +1,135 @@
+package laukit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+func TestNonceManager(t *testing.T) {
+	pending := uint64(5)
+	stub := newStubRPC(t, map[string]stubHandler{
+		"eth_getTransactionCount": func([]json.RawMessage) (interface{}, error) {
+			return hexutil.Uint64(pending), nil
+		},
+	})
+	ecl := stub.ecl(t, WithNonceManager(NewNonceManager()))
+	from := common.HexToAddress("0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17")
+	ctx := context.Background()
+
+	t.Run("concurrent", func(t *testing.T) {
+		var mu sync.Mutex
+		seen := map[uint64]bool{}
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				nonce, err := ecl.Nonces.Next(ctx, ecl, from)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if seen[nonce] {
+					t.Errorf("duplicate nonce %d", nonce)
+				}
+				seen[nonce] = true
+			}()
+		}
+		wg.Wait()
+		for n := uint64(5); n < 55; n++ {
+			if !seen[n] {
+				t.Fatalf("nonce %d not allocated", n)
+			}
+		}
+		if c := stub.count("eth_getTransactionCount"); c != 1 {
+			t.Fatalf("expected a single sync, got %d", c)
+		}
+	})
+
+	t.Run("release", func(t *testing.T) {
+		ecl.Nonces.Release(ecl, from, 10)
+		ecl.Nonces.Release(ecl, from, 54)
+		for _, want := range []uint64{10, 54, 55} {
+			got, err := ecl.Nonces.Next(ctx, ecl, from)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Fatalf("want nonce %d, got %d", want, got)
+			}
+		}
+	})
+
+	t.Run("build error", func(t *testing.T) {
+		// 估算 gas 失败时归还 nonce, 下一笔交易复用同一个 nonce
+		stub.handle("eth_estimateGas", func([]json.RawMessage) (interface{}, error) {
+			return nil, &stubError{Code: 3, Message: "execution reverted"}
+		})
+		sender := common.HexToAddress("0x02")
+		req := &TransactionReq{From: sender, To: from, GasPrice: big.NewInt(1)}
+		if _, err := EclNewTransaction(ctx, ecl, req); err == nil {
+			t.Fatal("expected estimate gas error")
+		}
+		if req.Nonce != nil {
+			t.Fatalf("nonce %d should be cleared", req.Nonce)
+		}
+		got, err := ecl.Nonces.Next(ctx, ecl, sender)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != pending {
+			t.Fatalf("want released nonce %d, got %d", pending, got)
+		}
+	})
+
+	t.Run("resync", func(t *testing.T) {
+		auth, _ := NewEAuth(WithEcl(ecl), WithPrivateKey("0x1234567890123456789012345678901234567890123456789012345678901234"))
+		sender := crypto.PubkeyToAddress(auth.Private.PublicKey)
+		stub.handle("eth_gasPrice", func([]json.RawMessage) (interface{}, error) { return "0x1", nil })
+		stub.handle("eth_sendRawTransaction", func([]json.RawMessage) (interface{}, error) {
+			return nil, &stubError{Code: -32000, Message: "nonce too low"}
+		})
+		pending = 20
+		req := &TransactionReq{From: sender, To: from, GasLimit: 21000, GasPrice: big.NewInt(1)}
+		tx, err := EclNewTransaction(ctx, ecl, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tx.Nonce() != 20 {
+			t.Fatalf("want nonce 20, got %d", tx.Nonce())
+		}
+		signed, err := NewKeySigner(auth.Private).SignTx(ctx, tx, ecl.ChainId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 其他进程已经用掉了 20..99, 广播返回 nonce too low 后重新同步
+		pending = 100
+		if _, _, err := EclSendTransaction(ctx, ecl, signed); !IsNonceTooLow(err) {
+			t.Fatalf("expected nonce too low, got %v", err)
+		}
+		tx, err = EclNewTransaction(ctx, ecl, &TransactionReq{From: sender, To: from, GasLimit: 21000, GasPrice: big.NewInt(1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tx.Nonce() != 100 {
+			t.Fatalf("want nonce 100 from node after resync, got %d", tx.Nonce())
+		}
+
+		// Eauth.NewTransactor 也从 NonceManager 分配
+		opts, err := auth.NewTransactor(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts.Nonce.Uint64() != 101 {
+			t.Fatalf("want transactor nonce 101, got %d", opts.Nonce.Uint64())
+		}
+	})
+}
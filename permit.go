@@ -0,0 +1,153 @@
+package laukit
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/laukkw/kwstart/errors"
+	"math/big"
+)
+
+// ErrPermitDomainMismatch 本地计算的 domain separator 与合约中的不一致, 签名会被合约拒绝
+var ErrPermitDomainMismatch = errors.New("permit domain separator does not match token")
+
+var erc2612 = MustParseHumanABI(
+	"function name() view returns (string)",
+	"function version() view returns (string)",
+	"function nonces(address owner) view returns (uint256)",
+	"function DOMAIN_SEPARATOR() view returns (bytes32)",
+	"function permit(address owner, address spender, uint256 value, uint256 deadline, uint8 v, bytes32 r, bytes32 s)",
+)
+
+// Permit 签好名的 EIP-2612 permit, Data 为 permit(...) 的调用数据
+type Permit struct {
+	Token     common.Address
+	Owner     common.Address
+	Spender   common.Address
+	Value     *big.Int
+	Nonce     *big.Int
+	Deadline  *big.Int
+	V         uint8
+	R         [32]byte
+	S         [32]byte
+	Signature []byte
+	Data      []byte
+}
+
+// EclBuildPermit 读取 token 的 name / version / nonces / DOMAIN_SEPARATOR, 用 auth 签名 Permit
+// 合约没有 version() (revert 或返回空数据) 时按 "1" 处理, 本地计算的 domain separator 与合约不一致时返回 ErrPermitDomainMismatch
+func EclBuildPermit(ctx context.Context, ecl *Ecl, auth *Eauth, token, spender common.Address, value, deadline *big.Int) (*Permit, error) {
+	if auth == nil {
+		return nil, errors.New("build permit: auth is nil")
+	}
+	signer, err := auth.signer()
+	if err != nil {
+		return nil, fmt.Errorf("build permit: %w", err)
+	}
+	owner := signer.Address()
+
+	name, err := EclCallContract(ctx, ecl, token, erc2612, "name", nil)
+	if err != nil {
+		return nil, fmt.Errorf("build permit: %w", err)
+	}
+	version, err := eclPermitVersion(ctx, ecl, token)
+	if err != nil {
+		return nil, fmt.Errorf("build permit: %w", err)
+	}
+	nonce, err := EclCallContract(ctx, ecl, token, erc2612, "nonces", nil, owner)
+	if err != nil {
+		return nil, fmt.Errorf("build permit: %w", err)
+	}
+	separator, err := EclCallContract(ctx, ecl, token, erc2612, "DOMAIN_SEPARATOR", nil)
+	if err != nil {
+		return nil, fmt.Errorf("build permit: %w", err)
+	}
+
+	permit := &Permit{
+		Token:    token,
+		Owner:    owner,
+		Spender:  spender,
+		Value:    value,
+		Nonce:    nonce[0].(*big.Int),
+		Deadline: deadline,
+	}
+	typedData := permit.typedData(name[0].(string), version, ecl.ChainId)
+	domainSeparator, err := DomainSeparator(typedData)
+	if err != nil {
+		return nil, err
+	}
+	if domainSeparator != common.Hash(separator[0].([32]byte)) {
+		return nil, fmt.Errorf("%w: computed %s, token %s", ErrPermitDomainMismatch, domainSeparator.Hex(), common.Hash(separator[0].([32]byte)).Hex())
+	}
+	sig, err := auth.SignTypedData(ctx, typedData)
+	if err != nil {
+		return nil, err
+	}
+	permit.Signature = sig
+	copy(permit.R[:], sig[:32])
+	copy(permit.S[:], sig[32:64])
+	permit.V = sig[64]
+	permit.Data, err = EncodeInputData(erc2612, "permit", permit.Owner, permit.Spender, permit.Value, permit.Deadline, permit.V, permit.R, permit.S)
+	if err != nil {
+		return nil, err
+	}
+	return permit, nil
+}
+
+// eclPermitVersion 读取 token 的 version(), 合约没有该方法 (revert 或返回空数据) 时为 "1"
+// 限流等节点错误原样返回, 避免用错误的 version 签名
+func eclPermitVersion(ctx context.Context, ecl *Ecl, token common.Address) (string, error) {
+	method := erc2612.Methods["version"]
+	output, err := ecl.CallContract(ctx, ethereum.CallMsg{To: &token, Data: method.ID}, nil)
+	if err != nil {
+		if IsRevertError(err) {
+			return "1", nil
+		}
+		return "", fmt.Errorf("call %s error: %w", method.Sig, err)
+	}
+	if len(output) == 0 {
+		return "1", nil
+	}
+	values, err := method.Outputs.UnpackValues(output)
+	if err != nil {
+		return "", fmt.Errorf("decode %s output error: %w", method.Sig, err)
+	}
+	return values[0].(string), nil
+}
+
+func (p *Permit) typedData(name, version string, chainId *big.Int) *TypedData {
+	types := map[string][]TypedDataField{
+		eip712DomainType: {
+			{Name: "name", Type: "string"},
+			{Name: "version", Type: "string"},
+			{Name: "chainId", Type: "uint256"},
+			{Name: "verifyingContract", Type: "address"},
+		},
+		"Permit": {
+			{Name: "owner", Type: "address"},
+			{Name: "spender", Type: "address"},
+			{Name: "value", Type: "uint256"},
+			{Name: "nonce", Type: "uint256"},
+			{Name: "deadline", Type: "uint256"},
+		},
+	}
+	return &TypedData{
+		Types:       types,
+		PrimaryType: "Permit",
+		Domain: TypedDataDomain{
+			Name:              name,
+			Version:           version,
+			ChainId:           (*math.HexOrDecimal256)(chainId),
+			VerifyingContract: p.Token.Hex(),
+		},
+		Message: map[string]interface{}{
+			"owner":    p.Owner.Hex(),
+			"spender":  p.Spender.Hex(),
+			"value":    p.Value,
+			"nonce":    p.Nonce,
+			"deadline": p.Deadline,
+		},
+	}
+}
@@ -0,0 +1,106 @@
+package laukit
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/laukkw/kwstart/errors"
+	"math/big"
+	"testing"
+)
+
+func TestEclBuildPermit(t *testing.T) {
+	token := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	spender := common.HexToAddress("0x487ee5d805b3c95eb23055dc92aad29a89961f17")
+	key, _ := crypto.ToECDSA(crypto.Keccak256([]byte("cow")))
+	owner := crypto.PubkeyToAddress(key.PublicKey)
+
+	// 按 EIP-2612 的定义独立计算 domain separator, version() 不存在时为 "1"
+	domainType := crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	encoded, _ := AbiCoder([]string{"bytes32", "bytes32", "bytes32", "uint256", "address"}, []interface{}{
+		domainType, crypto.Keccak256Hash([]byte("Test Token")), crypto.Keccak256Hash([]byte("1")), big.NewInt(1), token,
+	})
+	separator := crypto.Keccak256Hash(encoded)
+
+	stub := newStubRPC(t, map[string]stubHandler{
+		"eth_call": stubContract(t, erc2612, map[string]stubContractMethod{
+			"name": func(common.Address, []interface{}) ([]interface{}, error) {
+				return []interface{}{"Test Token"}, nil
+			},
+			"nonces": func(_ common.Address, args []interface{}) ([]interface{}, error) {
+				if args[0].(common.Address) != owner {
+					t.Errorf("unexpected owner %s", args[0])
+				}
+				return []interface{}{big.NewInt(3)}, nil
+			},
+			"DOMAIN_SEPARATOR": func(common.Address, []interface{}) ([]interface{}, error) {
+				return []interface{}{separator}, nil
+			},
+		}),
+	})
+	auth, _ := NewEAuth(WithEcl(stub.ecl(t)))
+	auth.Private = key
+
+	permit, err := EclBuildPermit(context.Background(), auth.Ecl, auth, token, spender, big.NewInt(1e18), big.NewInt(1700000000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if permit.Nonce.Int64() != 3 || permit.Owner != owner || (permit.V != 27 && permit.V != 28) {
+		t.Fatalf("unexpected permit %+v", permit)
+	}
+
+	// 独立计算 Permit 的 struct hash 并恢复签名地址
+	permitType := crypto.Keccak256Hash([]byte("Permit(address owner,address spender,uint256 value,uint256 nonce,uint256 deadline)"))
+	encoded, _ = AbiCoder([]string{"bytes32", "address", "address", "uint256", "uint256", "uint256"}, []interface{}{
+		permitType, owner, spender, big.NewInt(1e18), big.NewInt(3), big.NewInt(1700000000),
+	})
+	digest := crypto.Keccak256([]byte{0x19, 0x01}, separator.Bytes(), crypto.Keccak256(encoded))
+	signer, err := recoverHashSigner(digest, permit.Signature)
+	if err != nil || signer != owner {
+		t.Fatalf("unexpected signer %s %v", signer.Hex(), err)
+	}
+
+	name, args, err := DecodeInputData(erc2612, permit.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "permit" || args["spender"] != spender || args["v"] != permit.V || args["r"] != permit.R {
+		t.Fatalf("unexpected permit calldata %s %v", name, args)
+	}
+
+	stub.handle("eth_call", stubContract(t, erc2612, map[string]stubContractMethod{
+		"name":    func(common.Address, []interface{}) ([]interface{}, error) { return []interface{}{"Test Token"}, nil },
+		"version": func(common.Address, []interface{}) ([]interface{}, error) { return []interface{}{"2"}, nil },
+		"nonces":  func(common.Address, []interface{}) ([]interface{}, error) { return []interface{}{big.NewInt(0)}, nil },
+		"DOMAIN_SEPARATOR": func(common.Address, []interface{}) ([]interface{}, error) {
+			return []interface{}{separator}, nil
+		},
+	}))
+	if _, err := EclBuildPermit(context.Background(), auth.Ecl, auth, token, spender, big.NewInt(1), big.NewInt(1)); !errors.Is(err, ErrPermitDomainMismatch) {
+		t.Fatalf("expected domain mismatch, got %v", err)
+	}
+
+	// version() 返回空数据时按 "1" 处理, 其它节点错误不能回退
+	for _, c := range []struct {
+		version stubContractMethod
+		ok      bool
+	}{
+		{func(common.Address, []interface{}) ([]interface{}, error) { return nil, nil }, true},
+		{func(common.Address, []interface{}) ([]interface{}, error) {
+			return nil, &stubError{Code: -32005, Message: "request rate limited"}
+		}, false},
+	} {
+		stub.handle("eth_call", stubContract(t, erc2612, map[string]stubContractMethod{
+			"name":    func(common.Address, []interface{}) ([]interface{}, error) { return []interface{}{"Test Token"}, nil },
+			"version": c.version,
+			"nonces":  func(common.Address, []interface{}) ([]interface{}, error) { return []interface{}{big.NewInt(0)}, nil },
+			"DOMAIN_SEPARATOR": func(common.Address, []interface{}) ([]interface{}, error) {
+				return []interface{}{separator}, nil
+			},
+		}))
+		_, err := EclBuildPermit(context.Background(), auth.Ecl, auth, token, spender, big.NewInt(1), big.NewInt(1))
+		if (err == nil) != c.ok {
+			t.Fatalf("unexpected version fallback result %v, want ok %v", err, c.ok)
+		}
+	}
+}
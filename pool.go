@@ -0,0 +1,411 @@
+package laukit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type PoolStrategy int
+
+const (
+	// PoolRoundRobin 在健康的节点之间轮询
+	PoolRoundRobin PoolStrategy = iota
+	// PoolLowestLatency 优先使用延迟最低的健康节点
+	PoolLowestLatency
+)
+
+// EclPool 同一条链的多个 rpc 节点, 通过 http transport 在节点之间转发请求,
+// 出错或区块高度落后的节点在下一次健康检查成功之前不再使用
+type EclPool struct {
+	Strategy PoolStrategy
+	// MaxBlockLag 落后最高节点超过该区块数的节点视为不健康
+	MaxBlockLag uint64
+	// CheckInterval 健康检查间隔
+	CheckInterval time.Duration
+	// Transport 请求节点时使用的 transport, 默认 http.DefaultTransport
+	Transport http.RoundTripper
+	// Middleware 不为空时为每个节点单独创建中间件, 例如每个节点自己的限流
+	Middleware func(url string) []RpcInterceptor
+
+	chainId   *big.Int
+	endpoints []*poolEndpoint
+	eclOpts   []EclOptions
+	next      uint64
+	cancel    context.CancelFunc
+}
+
+type EclPoolOptions func(*EclPool)
+
+func WithPoolStrategy(strategy PoolStrategy) EclPoolOptions {
+	return func(p *EclPool) {
+		p.Strategy = strategy
+	}
+}
+
+func WithPoolMaxBlockLag(blocks uint64) EclPoolOptions {
+	return func(p *EclPool) {
+		p.MaxBlockLag = blocks
+	}
+}
+
+func WithPoolCheckInterval(interval time.Duration) EclPoolOptions {
+	return func(p *EclPool) {
+		p.CheckInterval = interval
+	}
+}
+
+func WithPoolTransport(transport http.RoundTripper) EclPoolOptions {
+	return func(p *EclPool) {
+		p.Transport = transport
+	}
+}
+
+// WithPoolMiddleware 为每个节点创建各自的中间件
+func WithPoolMiddleware(build func(url string) []RpcInterceptor) EclPoolOptions {
+	return func(p *EclPool) {
+		p.Middleware = build
+	}
+}
+
+// WithPoolEclOptions 创建 Ecl 时附加的选项
+func WithPoolEclOptions(opts ...EclOptions) EclPoolOptions {
+	return func(p *EclPool) {
+		p.eclOpts = append(p.eclOpts, opts...)
+	}
+}
+
+type poolEndpoint struct {
+	url       *url.URL
+	client    *rpc.Client
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	verified bool
+	healthy  bool
+	latency  time.Duration
+	height   uint64
+	err      error
+}
+
+// PoolEndpointStatus 节点的健康状态
+type PoolEndpointStatus struct {
+	URL     string
+	Healthy bool
+	Latency time.Duration
+	Height  uint64
+	Err     error
+}
+
+// NewEclPool 连接同一条链的多个 http(s) rpc 节点, 所有节点的 ChainId 必须一致
+// 返回的 Ecl 与 NewEcl 的用法相同, ecl.Pool 可以查看节点状态
+func NewEclPool(ctx context.Context, urls []string, opts ...EclPoolOptions) (*Ecl, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("rpc不可为空")
+	}
+	p := &EclPool{
+		Strategy:      PoolRoundRobin,
+		MaxBlockLag:   5,
+		CheckInterval: 15 * time.Second,
+		Transport:     http.DefaultTransport,
+	}
+	for _, o := range opts {
+		o(p)
+	}
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			p.closeEndpoints()
+			return nil, fmt.Errorf("rpc url %s 不是 http(s) 地址", raw)
+		}
+		transport := p.Transport
+		if p.Middleware != nil {
+			transport = newRpcTransport(p.Transport, p.Middleware(raw))
+		}
+		client, err := rpc.DialHTTPWithClient(raw, &http.Client{Transport: transport})
+		if err != nil {
+			p.closeEndpoints()
+			return nil, fmt.Errorf("rpc url %s 连接出错", raw)
+		}
+		p.endpoints = append(p.endpoints, &poolEndpoint{url: u, client: client, transport: transport})
+	}
+	if err := p.check(ctx); err != nil {
+		p.closeEndpoints()
+		return nil, err
+	}
+	if p.chainId == nil {
+		p.closeEndpoints()
+		return nil, fmt.Errorf("rpc url 连接出错")
+	}
+
+	ecl := &Ecl{Ctx: ctx, ChainId: new(big.Int).Set(p.chainId), Pool: p}
+	for _, o := range p.eclOpts {
+		o(ecl)
+	}
+	// 这个地址不会被真正请求, RoundTrip 会替换成具体节点的地址
+	var transport http.RoundTripper = p
+	if len(ecl.interceptors) > 0 {
+		transport = newRpcTransport(p, ecl.interceptors)
+	}
+	rpcClient, err := rpc.DialHTTPWithClient("http://laukit-pool", &http.Client{Transport: transport})
+	if err != nil {
+		p.closeEndpoints()
+		return nil, err
+	}
+	ecl.Client = ethclient.NewClient(rpcClient)
+	ecl.Rpc = rpcClient
+
+	checkCtx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+	go p.loop(checkCtx)
+	return ecl, nil
+}
+
+// Close 停止健康检查并关闭节点连接
+func (p *EclPool) Close() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.closeEndpoints()
+}
+
+func (p *EclPool) closeEndpoints() {
+	for _, ep := range p.endpoints {
+		ep.client.Close()
+	}
+}
+
+// Endpoints 返回所有节点的状态
+func (p *EclPool) Endpoints() []PoolEndpointStatus {
+	status := make([]PoolEndpointStatus, 0, len(p.endpoints))
+	for _, ep := range p.endpoints {
+		ep.mu.Lock()
+		status = append(status, PoolEndpointStatus{
+			URL:     ep.url.String(),
+			Healthy: ep.healthy,
+			Latency: ep.latency,
+			Height:  ep.height,
+			Err:     ep.err,
+		})
+		ep.mu.Unlock()
+	}
+	return status
+}
+
+func (p *EclPool) loop(ctx context.Context) {
+	if p.CheckInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(p.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = p.check(ctx)
+		}
+	}
+}
+
+// check 并发检查所有节点的 ChainId 与区块高度, 第一次检查时 ChainId 不一致返回错误
+func (p *EclPool) check(ctx context.Context) error {
+	type result struct {
+		chainId *big.Int
+		height  uint64
+		latency time.Duration
+		err     error
+	}
+	results := make([]result, len(p.endpoints))
+	var wg sync.WaitGroup
+	for i, ep := range p.endpoints {
+		wg.Add(1)
+		go func(i int, ep *poolEndpoint) {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+			var r result
+			ep.mu.Lock()
+			verified := ep.verified
+			ep.mu.Unlock()
+			if !verified {
+				var chainId hexutil.Big
+				if r.err = ep.client.CallContext(cctx, &chainId, "eth_chainId"); r.err != nil {
+					results[i] = r
+					return
+				}
+				r.chainId = (*big.Int)(&chainId)
+			}
+			start := time.Now()
+			var height hexutil.Uint64
+			r.err = ep.client.CallContext(cctx, &height, "eth_blockNumber")
+			r.latency, r.height = time.Since(start), uint64(height)
+			results[i] = r
+		}(i, ep)
+	}
+	wg.Wait()
+
+	var maxHeight uint64
+	for i, r := range results {
+		if r.chainId != nil {
+			if p.chainId == nil {
+				p.chainId = r.chainId
+			} else if p.chainId.Cmp(r.chainId) != 0 {
+				if p.cancel == nil {
+					return fmt.Errorf("rpc url %s chainId %v 与 %v 不一致", p.endpoints[i].url, r.chainId, p.chainId)
+				}
+				results[i].err = fmt.Errorf("chainId %v mismatch", r.chainId)
+				continue
+			}
+		}
+		if r.err == nil && r.height > maxHeight {
+			maxHeight = r.height
+		}
+	}
+	for i, ep := range p.endpoints {
+		r := results[i]
+		ep.mu.Lock()
+		if r.chainId != nil && r.err == nil {
+			ep.verified = true
+		}
+		ep.err = r.err
+		if r.err == nil {
+			ep.latency, ep.height = r.latency, r.height
+			if ep.verified && r.height+p.MaxBlockLag < maxHeight {
+				ep.err = fmt.Errorf("block height %d behind %d", r.height, maxHeight)
+			}
+		}
+		ep.healthy = ep.verified && ep.err == nil
+		ep.mu.Unlock()
+	}
+	return nil
+}
+
+// order 返回本次请求尝试节点的顺序, 没有健康节点时尝试全部节点
+func (p *EclPool) order() []*poolEndpoint {
+	var healthy, rest []*poolEndpoint
+	for _, ep := range p.endpoints {
+		ep.mu.Lock()
+		if ep.healthy {
+			healthy = append(healthy, ep)
+		} else {
+			rest = append(rest, ep)
+		}
+		ep.mu.Unlock()
+	}
+	if len(healthy) == 0 {
+		healthy, rest = rest, nil
+	}
+	switch p.Strategy {
+	case PoolLowestLatency:
+		sort.SliceStable(healthy, func(i, j int) bool {
+			return healthy[i].getLatency() < healthy[j].getLatency()
+		})
+	default:
+		n := int(atomic.AddUint64(&p.next, 1) % uint64(len(healthy)))
+		healthy = append(healthy[n:], healthy[:n]...)
+	}
+	return append(healthy, rest...)
+}
+
+func (ep *poolEndpoint) getLatency() time.Duration {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	return ep.latency
+}
+
+func (ep *poolEndpoint) fail(err error) {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	ep.healthy = false
+	ep.err = err
+}
+
+// RoundTrip 实现 http.RoundTripper, 按 Strategy 选择节点, 节点出错时换下一个节点
+// 广播交易的请求 (NeverRetryMethods) 只发给一个节点, 出错时不会换节点重发
+func (p *EclPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+	broadcast := isBroadcast(rpcMethods(body))
+	endpoints := p.order()
+	var lastErr error
+	for i, ep := range endpoints {
+		r := req.Clone(req.Context())
+		r.URL = ep.url
+		r.Host = ep.url.Host
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		resp, err := ep.transport.RoundTrip(r)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError && resp.StatusCode != http.StatusTooManyRequests {
+			respBody, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err == nil {
+				resp.Body = io.NopCloser(bytes.NewReader(respBody))
+				resp.ContentLength = int64(len(respBody))
+				providerErr := rpcProviderError(respBody)
+				if providerErr == nil {
+					return resp, nil
+				}
+				// 节点自身的错误, 换节点; 最后一个节点或广播请求时把原响应交给调用方
+				ep.fail(fmt.Errorf("rpc url %s: %w", ep.url, providerErr))
+				if broadcast || i == len(endpoints)-1 || req.Context().Err() != nil {
+					return resp, nil
+				}
+				continue
+			}
+		} else if err == nil {
+			resp.Body.Close()
+			err = fmt.Errorf("rpc url %s: %s", ep.url, resp.Status)
+		}
+		ep.fail(err)
+		lastErr = err
+		if broadcast || req.Context().Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// poolFailoverCodes 表示节点自身出错的 JSON-RPC 错误码: 限流和内部错误
+var poolFailoverCodes = map[int]bool{429: true, -32005: true, -32603: true}
+
+// rpcProviderError 响应中有节点自身的错误时返回该错误, 合约 revert 等请求本身的错误不算
+func rpcProviderError(body []byte) error {
+	for _, msg := range rpcResponseMessages(body) {
+		if msg.Error == nil {
+			continue
+		}
+		if poolFailoverCodes[msg.Error.Code] {
+			return fmt.Errorf("rpc error %d: %s", msg.Error.Code, msg.Error.Message)
+		}
+		text := strings.ToLower(msg.Error.Message)
+		for _, s := range retryableRpcMessages {
+			if strings.Contains(text, s) {
+				return fmt.Errorf("rpc error %d: %s", msg.Error.Code, msg.Error.Message)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package laukit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/laukkw/kwstart/errors"
+	"testing"
+)
+
+func TestEclPool(t *testing.T) {
+	blockNumber := func(n string) stubHandler {
+		return func([]json.RawMessage) (interface{}, error) { return n, nil }
+	}
+	healthy := newStubRPC(t, map[string]stubHandler{"eth_blockNumber": blockNumber("0x64")})
+	lagging := newStubRPC(t, map[string]stubHandler{"eth_blockNumber": blockNumber("0x10")})
+	broken := newStubRPC(t, map[string]stubHandler{"eth_blockNumber": blockNumber("0x64")})
+
+	ecl, err := NewEclPool(context.Background(), []string{healthy.URL, lagging.URL, broken.URL}, WithPoolCheckInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ecl.Pool.Close()
+	if ecl.ChainId.Int64() != 1 {
+		t.Fatalf("unexpected chain id %v", ecl.ChainId)
+	}
+	status := ecl.Pool.Endpoints()
+	if !status[0].Healthy || status[1].Healthy || !status[2].Healthy {
+		t.Fatalf("unexpected endpoint status %+v", status)
+	}
+
+	// 节点宕机后请求转到其他节点
+	broken.Close()
+	for i := 0; i < 4; i++ {
+		n, err := ecl.BlockNumber(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 100 {
+			t.Fatalf("request routed to lagging endpoint, got height %d", n)
+		}
+	}
+	if ecl.Pool.Endpoints()[2].Healthy {
+		t.Fatal("broken endpoint still healthy")
+	}
+	if lagging.count("eth_blockNumber") != 1 {
+		t.Fatalf("lagging endpoint used %d times", lagging.count("eth_blockNumber"))
+	}
+
+	other := newStubRPC(t, map[string]stubHandler{
+		"eth_chainId":     func([]json.RawMessage) (interface{}, error) { return "0x38", nil },
+		"eth_blockNumber": blockNumber("0x64"),
+	})
+	if _, err := NewEclPool(context.Background(), []string{healthy.URL, other.URL}); err == nil {
+		t.Fatal("expected chain id mismatch error")
+	}
+}
+
+func TestEclPoolFailover(t *testing.T) {
+	ctx := context.Background()
+	blockNumber := func([]json.RawMessage) (interface{}, error) { return "0x64", nil }
+	sendRaw := func([]json.RawMessage) (interface{}, error) { return common.Hash{1}, nil }
+	limited := newStubRPC(t, map[string]stubHandler{
+		"eth_blockNumber": blockNumber,
+		"eth_gasPrice": func([]json.RawMessage) (interface{}, error) {
+			return nil, &stubError{Code: -32005, Message: "daily request count exceeded, request rate limited"}
+		},
+		"eth_sendRawTransaction": func([]json.RawMessage) (interface{}, error) {
+			return nil, &stubError{Code: -32005, Message: "request rate limited"}
+		},
+	})
+	ok := newStubRPC(t, map[string]stubHandler{
+		"eth_blockNumber":        blockNumber,
+		"eth_gasPrice":           func([]json.RawMessage) (interface{}, error) { return "0x1", nil },
+		"eth_sendRawTransaction": sendRaw,
+	})
+	ecl, err := NewEclPool(ctx, []string{limited.URL, ok.URL}, WithPoolCheckInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ecl.Pool.Close()
+
+	// JSON-RPC 错误体中的限流错误也会换节点, 并把节点标记为不健康
+	for i := 0; i < 2; i++ {
+		if price, err := ecl.SuggestGasPrice(ctx); err != nil || price.Int64() != 1 {
+			t.Fatalf("unexpected gas price %v %v", price, err)
+		}
+	}
+	if ecl.Pool.Endpoints()[0].Healthy || !ecl.Pool.Endpoints()[1].Healthy {
+		t.Fatalf("unexpected endpoint status %+v", ecl.Pool.Endpoints())
+	}
+
+	// 没有健康节点时广播请求只发给一个节点
+	ecl.Pool.endpoints[1].fail(errors.New("down"))
+	var sent int
+	for i := 0; i < 2; i++ {
+		var hash common.Hash
+		if err := ecl.Rpc.CallContext(ctx, &hash, "eth_sendRawTransaction", "0x00"); err == nil {
+			sent++
+		}
+	}
+	if limited.count("eth_sendRawTransaction")+ok.count("eth_sendRawTransaction") != 2 || sent != ok.count("eth_sendRawTransaction") {
+		t.Fatalf("broadcast failed over: limited %d ok %d sent %d",
+			limited.count("eth_sendRawTransaction"), ok.count("eth_sendRawTransaction"), sent)
+	}
+}
+
+func TestEclPoolBroadcastTransportError(t *testing.T) {
+	ctx := context.Background()
+	blockNumber := func([]json.RawMessage) (interface{}, error) { return "0x64", nil }
+	sendRaw := func([]json.RawMessage) (interface{}, error) { return common.Hash{1}, nil }
+	broken := newStubRPC(t, map[string]stubHandler{"eth_blockNumber": blockNumber, "eth_sendRawTransaction": sendRaw})
+	ok := newStubRPC(t, map[string]stubHandler{"eth_blockNumber": blockNumber, "eth_sendRawTransaction": sendRaw})
+	ecl, err := NewEclPool(ctx, []string{broken.URL, ok.URL}, WithPoolCheckInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ecl.Pool.Close()
+	broken.Close()
+
+	// 轮询时两次请求的第一个节点不同, 发给宕机节点的那次直接返回错误, 不会再发给 ok
+	var failed int
+	for i := 0; i < 2; i++ {
+		var hash common.Hash
+		if err := ecl.Rpc.CallContext(ctx, &hash, "eth_sendRawTransaction", "0x00"); err != nil {
+			failed++
+		}
+	}
+	if failed != 1 || ok.count("eth_sendRawTransaction") != 1 {
+		t.Fatalf("broadcast failed over: failed %d sent %d", failed, ok.count("eth_sendRawTransaction"))
+	}
+}
@@ -0,0 +1,204 @@
+package laukit
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/laukkw/kwstart/errors"
+	"time"
+)
+
+var (
+	// ErrReceiptReorged 达到确认数之前收据所在的区块被重组
+	ErrReceiptReorged = errors.New("receipt block reorged before confirmation")
+	// ErrReceiptDropped 达到确认数之前收据消失
+	ErrReceiptDropped = errors.New("receipt dropped before confirmation")
+)
+
+type ReceiptEventType int
+
+const (
+	// ReceiptFound 第一次拿到收据, 或重组后在新区块拿到收据
+	ReceiptFound ReceiptEventType = iota
+	// ReceiptConfirmation 确认数增加
+	ReceiptConfirmation
+	// ReceiptReorged 收据所在区块 hash 发生变化
+	ReceiptReorged
+	// ReceiptDropped 收据消失
+	ReceiptDropped
+)
+
+type ReceiptEvent struct {
+	Type          ReceiptEventType
+	Receipt       *types.Receipt
+	Confirmations uint64
+}
+
+// ReceiptWaiter 等待交易收据达到指定确认数
+// websocket 连接时订阅新区块, http 连接时按 PollInterval 轮询
+type ReceiptWaiter struct {
+	Ecl *Ecl
+	// Confirmations 需要的确认数, 收据所在区块算 1 个, 默认为 1
+	Confirmations uint64
+	// PollInterval 轮询间隔, 默认 1 秒
+	PollInterval time.Duration
+	// Timeout ctx 没有 deadline 时的超时时间, 默认 120 秒
+	Timeout time.Duration
+	// ContinueOnReorg 为 true 时重组或收据消失后继续等待交易重新打包, 否则返回 ErrReceiptReorged / ErrReceiptDropped
+	ContinueOnReorg bool
+	// OnEvent 不为空时收到收据相关的事件
+	OnEvent func(ReceiptEvent)
+}
+
+func NewReceiptWaiter(ecl *Ecl, confirmations uint64) *ReceiptWaiter {
+	return &ReceiptWaiter{Ecl: ecl, Confirmations: confirmations}
+}
+
+// Wait 等待多个交易中任意一个达到确认数, 多个 hash 用于相同 nonce 的替换交易
+func (w *ReceiptWaiter) Wait(ctx context.Context, txHashes ...common.Hash) (*types.Receipt, error) {
+	if w.Ecl == nil {
+		return nil, fmt.Errorf("%s ecl client is nil", errorPath)
+	}
+	if len(txHashes) == 0 {
+		return nil, fmt.Errorf("%s wait receipt error: tx hash is empty", errorPath)
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		timeout := w.Timeout
+		if timeout == 0 {
+			timeout = 120 * time.Second // default timeout of 120 seconds
+		}
+		var clearTimeout context.CancelFunc
+		ctx, clearTimeout = context.WithTimeout(ctx, timeout)
+		defer clearTimeout()
+	}
+	interval := w.PollInterval
+	if interval == 0 {
+		interval = time.Second
+	}
+
+	// http 连接不支持订阅, SubscribeNewHead 直接返回错误
+	heads := make(chan *types.Header, 16)
+	var subErr <-chan error
+	var tick <-chan time.Time
+	if sub, err := w.Ecl.SubscribeNewHead(ctx, heads); err == nil {
+		defer sub.Unsubscribe()
+		subErr = sub.Err()
+	} else {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	state := &receiptWaitState{waiter: w, hashes: txHashes}
+	var head *types.Header
+	for {
+		receipt, err := state.check(ctx, head)
+		if err != nil || receipt != nil {
+			return receipt, err
+		}
+		head = nil
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("ethwallet, WaitReceipt for %v: %w", txHashes, ctx.Err())
+		case head = <-heads:
+		case <-subErr:
+			// 订阅断开, 退回轮询
+			subErr = nil
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		case <-tick:
+		}
+	}
+}
+
+type receiptWaitState struct {
+	waiter *ReceiptWaiter
+	hashes []common.Hash
+	found  *types.Receipt
+	seen   uint64
+}
+
+func (s *receiptWaitState) emit(typ ReceiptEventType, receipt *types.Receipt, confirmations uint64) {
+	if s.waiter.OnEvent != nil {
+		s.waiter.OnEvent(ReceiptEvent{Type: typ, Receipt: receipt, Confirmations: confirmations})
+	}
+}
+
+func (s *receiptWaitState) receipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	receipt, err := s.waiter.Ecl.TransactionReceipt(ctx, txHash)
+	if err != nil && !errors.Is(err, ethereum.NotFound) {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// check 返回达到确认数的收据, 未达到时返回 nil
+func (s *receiptWaitState) check(ctx context.Context, head *types.Header) (*types.Receipt, error) {
+	if s.found == nil {
+		for _, txHash := range s.hashes {
+			receipt, err := s.receipt(ctx, txHash)
+			if err != nil {
+				return nil, err
+			}
+			if receipt != nil {
+				s.found, s.seen = receipt, 0
+				s.emit(ReceiptFound, receipt, 0)
+				break
+			}
+		}
+		if s.found == nil {
+			return nil, nil
+		}
+	} else {
+		receipt, err := s.receipt(ctx, s.found.TxHash)
+		if err != nil {
+			return nil, err
+		}
+		if receipt == nil {
+			s.emit(ReceiptDropped, s.found, 0)
+			if !s.waiter.ContinueOnReorg {
+				return nil, fmt.Errorf("%w: %v", ErrReceiptDropped, s.found.TxHash)
+			}
+			s.found = nil
+			return nil, nil
+		}
+		if receipt.BlockHash != s.found.BlockHash {
+			s.emit(ReceiptReorged, receipt, 0)
+			if !s.waiter.ContinueOnReorg {
+				return nil, fmt.Errorf("%w: %v moved from block %v to %v", ErrReceiptReorged, receipt.TxHash, s.found.BlockHash, receipt.BlockHash)
+			}
+			s.found, s.seen = receipt, 0
+			s.emit(ReceiptFound, receipt, 0)
+		}
+	}
+
+	if s.waiter.Confirmations <= 1 {
+		return s.found, nil
+	}
+	var number uint64
+	if head != nil {
+		number = head.Number.Uint64()
+	} else {
+		n, err := s.waiter.Ecl.BlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+		number = n
+	}
+	block := s.found.BlockNumber.Uint64()
+	if number < block {
+		return nil, nil
+	}
+	confirmations := number - block + 1
+	if confirmations > s.seen {
+		s.seen = confirmations
+		s.emit(ReceiptConfirmation, s.found, confirmations)
+	}
+	if confirmations >= s.waiter.Confirmations {
+		return s.found, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,326 @@
+package laukit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/laukkw/kwstart/errors"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReceiptWaiter(t *testing.T) {
+	txHash := common.HexToHash("0x01")
+	var mu sync.Mutex
+	head := uint64(10)
+	blockHash := common.HexToHash("0xaa")
+	newStub := func() *stubRPC {
+		return newStubRPC(t, map[string]stubHandler{
+			"eth_blockNumber": func([]json.RawMessage) (interface{}, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				head++
+				return hexutil.Uint64(head), nil
+			},
+			"eth_getTransactionReceipt": func([]json.RawMessage) (interface{}, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				if blockHash == (common.Hash{}) {
+					return nil, nil
+				}
+				return &types.Receipt{
+					Status:      types.ReceiptStatusSuccessful,
+					Logs:        []*types.Log{},
+					TxHash:      txHash,
+					BlockHash:   blockHash,
+					BlockNumber: big.NewInt(10),
+				}, nil
+			},
+		})
+	}
+
+	t.Run("confirmations", func(t *testing.T) {
+		var events []ReceiptEvent
+		w := NewReceiptWaiter(newStub().ecl(t), 3)
+		w.PollInterval = time.Millisecond
+		w.OnEvent = func(e ReceiptEvent) { events = append(events, e) }
+		receipt, err := w.Wait(context.Background(), txHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if receipt.BlockHash != blockHash {
+			t.Fatalf("unexpected receipt %+v", receipt)
+		}
+		last := events[len(events)-1]
+		if events[0].Type != ReceiptFound || last.Type != ReceiptConfirmation || last.Confirmations < 3 {
+			t.Fatalf("unexpected events %+v", events)
+		}
+	})
+
+	t.Run("reorg", func(t *testing.T) {
+		mu.Lock()
+		head = 10
+		mu.Unlock()
+		w := NewReceiptWaiter(newStub().ecl(t), 100)
+		w.PollInterval = time.Millisecond
+		w.OnEvent = func(e ReceiptEvent) {
+			if e.Type == ReceiptConfirmation && e.Confirmations == 2 {
+				mu.Lock()
+				blockHash = common.HexToHash("0xbb")
+				mu.Unlock()
+			}
+		}
+		_, err := w.Wait(context.Background(), txHash)
+		if !errors.Is(err, ErrReceiptReorged) {
+			t.Fatalf("expected reorg error, got %v", err)
+		}
+	})
+
+	t.Run("dropped", func(t *testing.T) {
+		mu.Lock()
+		head = 10
+		mu.Unlock()
+		w := NewReceiptWaiter(newStub().ecl(t), 100)
+		w.PollInterval = time.Millisecond
+		w.OnEvent = func(e ReceiptEvent) {
+			if e.Type == ReceiptConfirmation {
+				mu.Lock()
+				blockHash = common.Hash{}
+				mu.Unlock()
+			}
+		}
+		_, err := w.Wait(context.Background(), txHash)
+		if !errors.Is(err, ErrReceiptDropped) {
+			t.Fatalf("expected dropped error, got %v", err)
+		}
+	})
+}
+
+// receiptTestService websocket 测试节点, 通过 rpc.Server 提供 eth_subscribe("newHeads")
+type receiptTestService struct {
+	mu        sync.Mutex
+	head      uint64
+	advance   bool // eth_blockNumber 每次调用前进一个区块
+	calls     int
+	blockHash common.Hash
+	// subscribed 每次订阅 newHeads 时收到推送新区块的函数
+	subscribed chan func(number uint64) error
+}
+
+func (s *receiptTestService) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1))
+}
+
+func (s *receiptTestService) BlockNumber() hexutil.Uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.advance {
+		s.head++
+	}
+	return hexutil.Uint64(s.head)
+}
+
+func (s *receiptTestService) GetTransactionReceipt(txHash common.Hash) (*types.Receipt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &types.Receipt{
+		Status:      types.ReceiptStatusSuccessful,
+		Logs:        []*types.Log{},
+		TxHash:      txHash,
+		BlockHash:   s.blockHash,
+		BlockNumber: big.NewInt(10),
+	}, nil
+}
+
+func (s *receiptTestService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	s.subscribed <- func(number uint64) error {
+		return notifier.Notify(sub.ID, &types.Header{Number: new(big.Int).SetUint64(number), Difficulty: big.NewInt(0)})
+	}
+	return sub, nil
+}
+
+func (s *receiptTestService) blockNumberCalls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// trackingListener 记录所有连接, 用于模拟 websocket 断开
+type trackingListener struct {
+	net.Listener
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func (l *trackingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.mu.Lock()
+		l.conns = append(l.conns, conn)
+		l.mu.Unlock()
+	}
+	return conn, err
+}
+
+func (l *trackingListener) closeConns() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, conn := range l.conns {
+		_ = conn.Close()
+	}
+	l.conns = nil
+}
+
+func newReceiptWsServer(t *testing.T, svc *receiptTestService) (*Ecl, *trackingListener) {
+	t.Helper()
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", svc); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewUnstartedServer(server.WebsocketHandler([]string{"*"}))
+	listener := &trackingListener{Listener: httpServer.Listener}
+	httpServer.Listener = listener
+	httpServer.Start()
+	t.Cleanup(func() {
+		listener.closeConns()
+		httpServer.Close()
+		server.Stop()
+	})
+	ecl, err := NewEcl(context.Background(), "ws"+strings.TrimPrefix(httpServer.URL, "http"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(ecl.Rpc.Close)
+	return ecl, listener
+}
+
+type receiptWaitResult struct {
+	receipt *types.Receipt
+	err     error
+}
+
+func waitReceiptAsync(w *ReceiptWaiter, txHash common.Hash) <-chan receiptWaitResult {
+	done := make(chan receiptWaitResult, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		receipt, err := w.Wait(ctx, txHash)
+		done <- receiptWaitResult{receipt, err}
+	}()
+	return done
+}
+
+func receiveWithin[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout")
+	}
+	var zero T
+	return zero
+}
+
+func TestReceiptWaiterSubscription(t *testing.T) {
+	txHash := common.HexToHash("0x01")
+
+	t.Run("confirmations", func(t *testing.T) {
+		svc := &receiptTestService{head: 10, blockHash: common.HexToHash("0xaa"), subscribed: make(chan func(uint64) error, 1)}
+		ecl, _ := newReceiptWsServer(t, svc)
+		var events []ReceiptEvent
+		w := NewReceiptWaiter(ecl, 3)
+		// 只能通过订阅拿到新区块
+		w.PollInterval = time.Hour
+		w.OnEvent = func(e ReceiptEvent) { events = append(events, e) }
+		done := waitReceiptAsync(w, txHash)
+
+		notify := receiveWithin(t, svc.subscribed)
+		for _, number := range []uint64{11, 12} {
+			if err := notify(number); err != nil {
+				t.Fatal(err)
+			}
+		}
+		result := receiveWithin(t, done)
+		if result.err != nil || result.receipt.BlockHash != svc.blockHash {
+			t.Fatalf("unexpected result %+v %v", result.receipt, result.err)
+		}
+		last := events[len(events)-1]
+		if events[0].Type != ReceiptFound || last.Type != ReceiptConfirmation || last.Confirmations != 3 {
+			t.Fatalf("unexpected events %+v", events)
+		}
+		// 只有第一次检查查询了区块高度
+		if calls := svc.blockNumberCalls(); calls != 1 {
+			t.Fatalf("unexpected eth_blockNumber calls %d", calls)
+		}
+	})
+
+	t.Run("reorg", func(t *testing.T) {
+		svc := &receiptTestService{head: 10, blockHash: common.HexToHash("0xaa"), subscribed: make(chan func(uint64) error, 1)}
+		ecl, _ := newReceiptWsServer(t, svc)
+		confirmed := make(chan struct{}, 1)
+		w := NewReceiptWaiter(ecl, 100)
+		w.PollInterval = time.Hour
+		w.OnEvent = func(e ReceiptEvent) {
+			if e.Type == ReceiptConfirmation && e.Confirmations == 2 {
+				confirmed <- struct{}{}
+			}
+		}
+		done := waitReceiptAsync(w, txHash)
+
+		notify := receiveWithin(t, svc.subscribed)
+		if err := notify(11); err != nil {
+			t.Fatal(err)
+		}
+		receiveWithin(t, confirmed)
+		svc.mu.Lock()
+		svc.blockHash = common.HexToHash("0xbb")
+		svc.mu.Unlock()
+		if err := notify(12); err != nil {
+			t.Fatal(err)
+		}
+		if result := receiveWithin(t, done); !errors.Is(result.err, ErrReceiptReorged) {
+			t.Fatalf("expected reorg error, got %v", result.err)
+		}
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		svc := &receiptTestService{head: 10, advance: true, blockHash: common.HexToHash("0xaa"), subscribed: make(chan func(uint64) error, 1)}
+		ecl, listener := newReceiptWsServer(t, svc)
+		started := make(chan struct{}, 1)
+		w := NewReceiptWaiter(ecl, 5)
+		w.PollInterval = time.Millisecond
+		w.OnEvent = func(e ReceiptEvent) {
+			if e.Type == ReceiptConfirmation && e.Confirmations == 2 {
+				started <- struct{}{}
+			}
+		}
+		done := waitReceiptAsync(w, txHash)
+
+		receiveWithin(t, svc.subscribed)
+		receiveWithin(t, started)
+		// 订阅断开后退回轮询, 轮询时 rpc 客户端重新连接
+		listener.closeConns()
+		result := receiveWithin(t, done)
+		if result.err != nil || result.receipt == nil {
+			t.Fatalf("unexpected result %+v %v", result.receipt, result.err)
+		}
+		if calls := svc.blockNumberCalls(); calls < 4 {
+			t.Fatalf("expected polling after subscription dropped, got %d eth_blockNumber calls", calls)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package laukit
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"math/big"
+)
+
+// ReplacePriceBump geth txpool 替换相同 nonce 交易要求的最低涨幅(百分比)
+var ReplacePriceBump int64 = 10
+
+// EclSpeedUpTransaction 用相同 nonce 和更高的费用重新发送交易, 返回的 WaitReceipt 等待原交易或替换交易任意一个被打包
+func EclSpeedUpTransaction(ctx context.Context, ecl *Ecl, auth *Eauth, tx *types.Transaction) (*types.Transaction, WaitReceipt, error) {
+	return eclReplaceTransaction(ctx, ecl, auth, tx, false)
+}
+
+// EclCancelTransaction 用相同 nonce 发送一笔给自己的 0 值转账来取消交易
+func EclCancelTransaction(ctx context.Context, ecl *Ecl, auth *Eauth, tx *types.Transaction) (*types.Transaction, WaitReceipt, error) {
+	return eclReplaceTransaction(ctx, ecl, auth, tx, true)
+}
+
+func eclReplaceTransaction(ctx context.Context, ecl *Ecl, auth *Eauth, tx *types.Transaction, cancel bool) (*types.Transaction, WaitReceipt, error) {
+	if ecl == nil || auth == nil || tx == nil {
+		return nil, nil, fmt.Errorf("%s replace transaction error: 请求为空", errorPath)
+	}
+	sender, err := auth.signer()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s replace transaction error: %w", errorPath, err)
+	}
+	chainId := ecl.ChainId
+	if tx.Type() != types.LegacyTxType || tx.Protected() {
+		chainId = tx.ChainId()
+	}
+	signer := types.LatestSignerForChainID(chainId)
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s replace transaction error: %w", errorPath, err)
+	}
+	if from != sender.Address() {
+		return nil, nil, fmt.Errorf("%s replace transaction error: tx sender %s is not %s", errorPath, from.Hex(), sender.Address().Hex())
+	}
+
+	to, value, data, gas, accessList := tx.To(), tx.Value(), tx.Data(), tx.Gas(), tx.AccessList()
+	if cancel {
+		to, value, data, gas, accessList = &from, big.NewInt(0), nil, 21000, nil
+	}
+
+	var replacement *types.Transaction
+	switch tx.Type() {
+	case types.DynamicFeeTxType:
+		tip := bumpReplaceFee(tx.GasTipCap())
+		if suggested, err := ecl.SuggestGasTipCap(ctx); err == nil && suggested.Cmp(tip) > 0 {
+			tip = suggested
+		}
+		feeCap := bumpReplaceFee(tx.GasFeeCap())
+		if suggested, err := ecl.SuggestGasPrice(ctx); err == nil && suggested.Cmp(feeCap) > 0 {
+			feeCap = suggested
+		}
+		if feeCap.Cmp(tip) < 0 {
+			feeCap = new(big.Int).Set(tip)
+		}
+		replacement = types.NewTx(&types.DynamicFeeTx{
+			ChainID:    chainId,
+			Nonce:      tx.Nonce(),
+			GasTipCap:  tip,
+			GasFeeCap:  feeCap,
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		})
+	case types.AccessListTxType:
+		replacement = types.NewTx(&types.AccessListTx{
+			ChainID:    chainId,
+			Nonce:      tx.Nonce(),
+			GasPrice:   replaceGasPrice(ctx, ecl, tx),
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		})
+	default:
+		replacement = types.NewTx(&types.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: replaceGasPrice(ctx, ecl, tx),
+			Gas:      gas,
+			To:       to,
+			Value:    value,
+			Data:     data,
+		})
+	}
+
+	signTx, err := sender.SignTx(ctx, replacement, chainId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s replace transaction sign error: %w", errorPath, err)
+	}
+	if err := ecl.SendTransaction(ctx, signTx); err != nil {
+		return nil, nil, fmt.Errorf("%s replace transaction send error: %w", errorPath, err)
+	}
+	hashes := []common.Hash{signTx.Hash(), tx.Hash()}
+	waitFn := func(ctx context.Context) (*types.Receipt, error) {
+		return eclWaitAnyReceipt(ctx, ecl, hashes...)
+	}
+	return signTx, waitFn, nil
+}
+
+func replaceGasPrice(ctx context.Context, ecl *Ecl, tx *types.Transaction) *big.Int {
+	gasPrice := bumpReplaceFee(tx.GasPrice())
+	if suggested, err := ecl.SuggestGasPrice(ctx); err == nil && suggested.Cmp(gasPrice) > 0 {
+		gasPrice = suggested
+	}
+	return gasPrice
+}
+
+// bumpReplaceFee 返回 geth 能接受的最低替换费用: 不低于 old*(100+bump)/100 且严格大于 old
+func bumpReplaceFee(old *big.Int) *big.Int {
+	if old == nil {
+		return big.NewInt(1)
+	}
+	bumped := new(big.Int).Mul(old, big.NewInt(100+ReplacePriceBump))
+	bumped.Div(bumped, big.NewInt(100))
+	if bumped.Cmp(old) <= 0 {
+		bumped.Add(old, big.NewInt(1))
+	}
+	return bumped
+}
@@ -0,0 +1,66 @@
+package laukit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+	"testing"
+)
+
+func TestEclReplaceTransaction(t *testing.T) {
+	stub := newStubRPC(t, map[string]stubHandler{
+		"eth_gasPrice":             func([]json.RawMessage) (interface{}, error) { return "0x1", nil },
+		"eth_maxPriorityFeePerGas": func([]json.RawMessage) (interface{}, error) { return "0x1", nil },
+		"eth_sendRawTransaction":   func([]json.RawMessage) (interface{}, error) { return common.Hash{}, nil },
+	})
+	ecl := stub.ecl(t)
+	key, _ := crypto.GenerateKey()
+	auth, _ := NewEAuth(WithEcl(ecl))
+	auth.Private = key
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x487ee5d805b3c95eb23055dc92aad29a89961f17")
+	signer := types.LatestSignerForChainID(ecl.ChainId)
+
+	txs := []types.TxData{
+		&types.LegacyTx{Nonce: 3, GasPrice: big.NewInt(1000), Gas: 50000, To: &to, Value: big.NewInt(5), Data: []byte{1}},
+		&types.AccessListTx{ChainID: ecl.ChainId, Nonce: 3, GasPrice: big.NewInt(5), Gas: 50000, To: &to},
+		&types.DynamicFeeTx{ChainID: ecl.ChainId, Nonce: 3, GasTipCap: big.NewInt(100), GasFeeCap: big.NewInt(1001), Gas: 50000, To: &to},
+	}
+	for _, data := range txs {
+		old := types.MustSignNewTx(key, signer, data)
+
+		sped, wait, err := EclSpeedUpTransaction(context.Background(), ecl, auth, old)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wait == nil || sped.Nonce() != old.Nonce() || sped.Type() != old.Type() || *sped.To() != to {
+			t.Fatalf("unexpected speed up tx %+v", sped)
+		}
+		switch old.Type() {
+		case types.LegacyTxType:
+			if sped.GasPrice().Int64() != 1100 {
+				t.Fatalf("legacy bump %v", sped.GasPrice())
+			}
+		case types.AccessListTxType:
+			// 5 * 110 / 100 == 5, 需要严格大于原值
+			if sped.GasPrice().Int64() != 6 {
+				t.Fatalf("access list bump %v", sped.GasPrice())
+			}
+		case types.DynamicFeeTxType:
+			if sped.GasTipCap().Int64() != 110 || sped.GasFeeCap().Int64() != 1101 {
+				t.Fatalf("dynamic fee bump %v %v", sped.GasTipCap(), sped.GasFeeCap())
+			}
+		}
+
+		cancel, _, err := EclCancelTransaction(context.Background(), ecl, auth, old)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *cancel.To() != from || cancel.Value().Sign() != 0 || len(cancel.Data()) != 0 || cancel.Gas() != 21000 {
+			t.Fatalf("unexpected cancel tx %+v", cancel)
+		}
+	}
+}
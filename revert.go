@@ -0,0 +1,189 @@
+package laukit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/laukkw/kwstart/errors"
+	"math/big"
+	"strings"
+)
+
+var (
+	revertErrorSelector = MustDecodeString("08c379a0") // Error(string)
+	revertPanicSelector = MustDecodeString("4e487b71") // Panic(uint256)
+)
+
+// PanicReasons solidity Panic(uint256) 错误码的含义
+var PanicReasons = map[uint64]string{
+	0x00: "generic compiler inserted panic",
+	0x01: "assert(false)",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "conversion into non-existent enum type",
+	0x22: "incorrectly encoded storage byte array",
+	0x31: "pop() on an empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to a zero-initialized variable of internal function type",
+}
+
+// RevertError 解析后的 revert 数据
+// Name 为 Error / Panic / 自定义 error 的名称, 无法识别时为空
+type RevertError struct {
+	Name      string
+	Args      []interface{}
+	Reason    string
+	PanicCode *big.Int
+	Data      []byte
+}
+
+func (e *RevertError) Error() string {
+	switch {
+	case e.Name == "Error":
+		return fmt.Sprintf("execution reverted: %s", e.Reason)
+	case e.Name == "Panic":
+		return fmt.Sprintf("execution reverted: panic 0x%x (%s)", e.PanicCode, e.Reason)
+	case e.Name != "":
+		args, _ := StringifyValues(e.Args)
+		return fmt.Sprintf("execution reverted: %s(%s)", e.Name, strings.Join(args, ", "))
+	case len(e.Data) > 0:
+		return fmt.Sprintf("execution reverted: unknown error %s", hexutil.Encode(e.Data))
+	}
+	return "execution reverted"
+}
+
+// DecodeRevert 按 Error(string) / Panic(uint256) / abis 中的自定义 error 解析 revert 数据
+func DecodeRevert(data []byte, abis ...abi.ABI) (*RevertError, error) {
+	revert := &RevertError{Data: data}
+	if len(data) < 4 {
+		return revert, nil
+	}
+	selector, payload := data[:4], data[4:]
+	switch {
+	case bytes.Equal(selector, revertErrorSelector):
+		values, err := AbiDecoderWithReturnedValues([]string{"string"}, payload)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode Error(string): %w", err)
+		}
+		revert.Name, revert.Args, revert.Reason = "Error", values, values[0].(string)
+		return revert, nil
+	case bytes.Equal(selector, revertPanicSelector):
+		values, err := AbiDecoderWithReturnedValues([]string{"uint256"}, payload)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode Panic(uint256): %w", err)
+		}
+		code := values[0].(*big.Int)
+		revert.Name, revert.Args, revert.PanicCode = "Panic", values, code
+		revert.Reason = "unknown panic code"
+		if code.IsUint64() {
+			if reason, ok := PanicReasons[code.Uint64()]; ok {
+				revert.Reason = reason
+			}
+		}
+		return revert, nil
+	}
+	for _, a := range abis {
+		for name, e := range a.Errors {
+			if !bytes.Equal(e.ID[:4], selector) {
+				continue
+			}
+			values, err := e.Inputs.UnpackValues(payload)
+			if err != nil {
+				return nil, fmt.Errorf("unable to decode error %s: %w", e.Sig, err)
+			}
+			revert.Name, revert.Args = name, values
+			return revert, nil
+		}
+	}
+	return revert, nil
+}
+
+// DecodeCallError 从 eth_call / eth_estimateGas 的错误中取出 revert 数据并解析
+// 没有 revert 数据时原样返回 err
+func DecodeCallError(err error, abis ...abi.ABI) error {
+	if err == nil {
+		return nil
+	}
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return err
+	}
+	hexData, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return err
+	}
+	data, derr := hexutil.Decode(hexData)
+	if derr != nil {
+		return err
+	}
+	revert, derr := DecodeRevert(data, abis...)
+	if derr != nil {
+		return err
+	}
+	return revert
+}
+
+// IsRevertError err 是否为合约 revert: 解析后的 *RevertError, 错误码 3 或消息包含 execution reverted
+// 限流、方法不存在等节点错误返回 false
+func IsRevertError(err error) bool {
+	var revert *RevertError
+	if errors.As(err, &revert) {
+		return true
+	}
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	return rpcErr.ErrorCode() == 3 || strings.Contains(strings.ToLower(rpcErr.Error()), "execution reverted")
+}
+
+// EclRevertReason 在交易所在区块的父区块状态上用 eth_call 重放失败的交易, 返回解析后的 revert 错误
+// receipt 状态为成功时返回 nil
+func EclRevertReason(ctx context.Context, ecl *Ecl, tx *types.Transaction, receipt *types.Receipt, abis ...abi.ABI) (*RevertError, error) {
+	if ecl == nil || tx == nil || receipt == nil {
+		return nil, fmt.Errorf("%s revert reason error: 请求为空", errorPath)
+	}
+	if receipt.Status == types.ReceiptStatusSuccessful {
+		return nil, nil
+	}
+	chainId := ecl.ChainId
+	if tx.Type() != types.LegacyTxType || tx.Protected() {
+		chainId = tx.ChainId()
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(chainId), tx)
+	if err != nil {
+		return nil, fmt.Errorf("%s revert reason error: %w", errorPath, err)
+	}
+	msg := ethereum.CallMsg{
+		From:       from,
+		To:         tx.To(),
+		Gas:        tx.Gas(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		msg.GasFeeCap, msg.GasTipCap = tx.GasFeeCap(), tx.GasTipCap()
+	} else {
+		msg.GasPrice = tx.GasPrice()
+	}
+	var block *big.Int
+	if receipt.BlockNumber != nil && receipt.BlockNumber.Sign() > 0 {
+		block = new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1))
+	}
+	_, err = ecl.CallContract(ctx, msg, block)
+	if err == nil {
+		return nil, fmt.Errorf("%s revert reason error: 重放交易没有 revert", errorPath)
+	}
+	var revert *RevertError
+	if errors.As(DecodeCallError(err, abis...), &revert) {
+		return revert, nil
+	}
+	return nil, fmt.Errorf("%s revert reason error: %w", errorPath, err)
+}
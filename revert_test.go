@@ -0,0 +1,130 @@
+package laukit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/laukkw/kwstart/errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+const revertTestABI = `[{"inputs":[{"internalType":"uint256","name":"available","type":"uint256"},{"internalType":"uint256","name":"required","type":"uint256"}],"name":"InsufficientBalance","type":"error"}]`
+
+func TestDecodeRevert(t *testing.T) {
+	errorData := MustDecodeString("08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"000000000000000000000000000000000000000000000000000000000000000e" +
+		"6e6f7420617574686f72697a6564000000000000000000000000000000000000")
+	revert, err := DecodeRevert(errorData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revert.Name != "Error" || revert.Reason != "not authorized" {
+		t.Fatalf("unexpected revert %v", revert)
+	}
+
+	panicData := MustDecodeString("4e487b71" + AddressPadding("11"))
+	revert, err = DecodeRevert(panicData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revert.Name != "Panic" || revert.PanicCode.Int64() != 0x11 || revert.Reason != "arithmetic underflow or overflow" {
+		t.Fatalf("unexpected revert %v", revert)
+	}
+
+	custom := MustParseABI(revertTestABI)
+	customData := append(custom.Errors["InsufficientBalance"].ID.Bytes()[:4], MustDecodeString(AddressPadding("01")+AddressPadding("02"))...)
+	revert, err = DecodeRevert(customData, custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revert.Name != "InsufficientBalance" || revert.Args[1].(*big.Int).Int64() != 2 {
+		t.Fatalf("unexpected revert %v", revert)
+	}
+	t.Log(revert)
+
+	revert, err = DecodeRevert(MustDecodeString("deadbeef"), custom)
+	if err != nil || revert.Name != "" {
+		t.Fatalf("unexpected revert %v %v", revert, err)
+	}
+}
+
+func TestEclNewTransactionRevert(t *testing.T) {
+	custom := MustParseABI(revertTestABI)
+	data := append(custom.Errors["InsufficientBalance"].ID.Bytes()[:4], MustDecodeString(AddressPadding("01")+AddressPadding("02"))...)
+	ecl := newStubRPC(t, map[string]stubHandler{
+		"eth_getTransactionCount": func([]json.RawMessage) (interface{}, error) { return "0x0", nil },
+		"eth_gasPrice":            func([]json.RawMessage) (interface{}, error) { return "0x1", nil },
+		"eth_estimateGas": func([]json.RawMessage) (interface{}, error) {
+			return nil, &stubError{Code: 3, Message: "execution reverted", Data: hexutil.Encode(data)}
+		},
+	}).ecl(t)
+
+	_, err := EclNewTransaction(context.Background(), ecl, &TransactionReq{
+		From:     common.HexToAddress("0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17"),
+		To:       common.HexToAddress("0x487ee5d805b3c95eb23055dc92aad29a89961f17"),
+		Data:     []byte{1, 2, 3, 4},
+		ErrorABI: &custom,
+	})
+	var revert *RevertError
+	if !errors.As(err, &revert) || revert.Name != "InsufficientBalance" {
+		t.Fatalf("expected custom revert error, got %v", err)
+	}
+}
+
+func TestEclRevertReason(t *testing.T) {
+	ctx := context.Background()
+	custom := MustParseABI(revertTestABI)
+	key, _ := crypto.HexToECDSA("1234567890123456789012345678901234567890123456789012345678901234")
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x487ee5d805b3c95eb23055dc92aad29a89961f17")
+	insufficient := append(custom.Errors["InsufficientBalance"].ID.Bytes()[:4], MustDecodeString(AddressPadding("01")+AddressPadding("02"))...)
+	panicData := append(common.CopyBytes(revertPanicSelector), MustDecodeString(AddressPadding("11"))...)
+
+	var revertData []byte
+	stub := newStubRPC(t, map[string]stubHandler{
+		"eth_call": func(params []json.RawMessage) (interface{}, error) {
+			var call map[string]interface{}
+			var block string
+			_ = json.Unmarshal(params[0], &call)
+			_ = json.Unmarshal(params[1], &block)
+			// 在父区块的状态上重放
+			if block != "0x63" || call["from"] != strings.ToLower(from.Hex()) || call["data"] != "0x01020304" {
+				return nil, &stubError{Code: -32000, Message: fmt.Sprintf("unexpected call %v at %s", call, block)}
+			}
+			return nil, &stubError{Code: 3, Message: "execution reverted", Data: hexutil.Encode(revertData)}
+		},
+	})
+	ecl := stub.ecl(t)
+	tx, _ := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+		ChainID: ecl.ChainId, Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 50000, To: &to, Data: []byte{1, 2, 3, 4},
+	}), types.LatestSignerForChainID(ecl.ChainId), key)
+	receipt := &types.Receipt{Status: types.ReceiptStatusFailed, BlockNumber: big.NewInt(100)}
+
+	revertData = insufficient
+	revert, err := EclRevertReason(ctx, ecl, tx, receipt, custom)
+	if err != nil || revert.Name != "InsufficientBalance" || len(revert.Args) != 2 || revert.Args[1].(*big.Int).Int64() != 2 {
+		t.Fatalf("unexpected custom revert %+v %v", revert, err)
+	}
+
+	revertData = panicData
+	revert, err = EclRevertReason(ctx, ecl, tx, receipt, custom)
+	if err != nil || revert.Name != "Panic" || revert.PanicCode == nil || revert.PanicCode.Uint64() != 0x11 {
+		t.Fatalf("unexpected panic revert %+v %v", revert, err)
+	}
+	if stub.count("eth_call") != 2 {
+		t.Fatalf("unexpected eth_call count %d", stub.count("eth_call"))
+	}
+
+	// 成功的交易不需要重放
+	receipt.Status = types.ReceiptStatusSuccessful
+	if revert, err := EclRevertReason(ctx, ecl, tx, receipt); revert != nil || err != nil {
+		t.Fatalf("unexpected revert for successful receipt %+v %v", revert, err)
+	}
+}
@@ -0,0 +1,217 @@
+package laukit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/laukkw/kwstart/errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SelectorRegistry 方法选择器到函数签名的索引, 用于解析没有 abi 的合约调用
+type SelectorRegistry struct {
+	mu   sync.RWMutex
+	sigs map[[4]byte][]string
+}
+
+// SelectorMatch 按某个签名成功解析的调用数据
+type SelectorMatch struct {
+	Method    string
+	Signature string
+	Values    []interface{}
+}
+
+// SelectorCollisionError 同一个选择器有多个签名都能解析调用数据
+type SelectorCollisionError struct {
+	Selector string
+	Matches  []SelectorMatch
+}
+
+func (e *SelectorCollisionError) Error() string {
+	sigs := make([]string, 0, len(e.Matches))
+	for _, m := range e.Matches {
+		sigs = append(sigs, m.Signature)
+	}
+	return fmt.Sprintf("selector %s is ambiguous: %s", e.Selector, strings.Join(sigs, ", "))
+}
+
+func NewSelectorRegistry() *SelectorRegistry {
+	return &SelectorRegistry{sigs: make(map[[4]byte][]string)}
+}
+
+// LoadSelectorFile 从本地签名文件加载, 文件格式见 Load
+func LoadSelectorFile(path string) (*SelectorRegistry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := NewSelectorRegistry()
+	if err := r.Load(f); err != nil {
+		return nil, fmt.Errorf("load selector file %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// Add 添加函数签名, 例如 transfer(address,uint256), 选择器由签名计算
+func (r *SelectorRegistry) Add(signature string) {
+	signature = strings.Join(strings.Fields(signature), "")
+	if !strings.Contains(signature, "(") {
+		return
+	}
+	var selector [4]byte
+	copy(selector[:], crypto.Keccak256([]byte(signature))[:4])
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.sigs[selector] {
+		if s == signature {
+			return
+		}
+	}
+	r.sigs[selector] = append(r.sigs[selector], signature)
+}
+
+// AddABI 添加 abi 中所有方法的签名
+func (r *SelectorRegistry) AddABI(contractABI abi.ABI) {
+	for _, m := range contractABI.Methods {
+		r.Add(m.Sig)
+	}
+}
+
+// Load 加载签名, 支持以下格式:
+//   - 4byte 风格的 JSON 对象: {"0xa9059cbb": ["transfer(address,uint256)"]} 或 {"0xa9059cbb": "transfer(address,uint256)"}
+//   - 4byte API 导出的 JSON 数组: [{"hex_signature": "0xa9059cbb", "text_signature": "transfer(address,uint256)"}]
+//   - 文本, 每行一个签名, 前面可以带选择器: "0xa9059cbb transfer(address,uint256)" 或 "a9059cbb,transfer(address,uint256)"
+//
+// 给出的选择器与签名计算出的不一致时返回错误
+func (r *SelectorRegistry) Load(reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+	switch data[0] {
+	case '{':
+		var dump map[string]json.RawMessage
+		if err := json.Unmarshal(data, &dump); err != nil {
+			return err
+		}
+		for key, raw := range dump {
+			var list []string
+			if err := json.Unmarshal(raw, &list); err != nil {
+				var single string
+				if err := json.Unmarshal(raw, &single); err != nil {
+					return err
+				}
+				list = []string{single}
+			}
+			for _, sig := range list {
+				if err := r.addWithSelector(key, sig); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case '[':
+		var dump []struct {
+			HexSignature  string `json:"hex_signature"`
+			TextSignature string `json:"text_signature"`
+		}
+		if err := json.Unmarshal(data, &dump); err != nil {
+			return err
+		}
+		for _, item := range dump {
+			if err := r.addWithSelector(item.HexSignature, item.TextSignature); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// 签名里没有空白和逗号之前的选择器前缀
+		var selector string
+		if i := strings.IndexAny(line, " \t,"); i >= 0 && i < strings.Index(line, "(") {
+			selector, line = line[:i], line[i+1:]
+		}
+		if err := r.addWithSelector(selector, line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// addWithSelector 添加签名, selector 不为空时必须与签名计算出的选择器一致, 否则 Lookup 会返回错误的签名
+func (r *SelectorRegistry) addWithSelector(selector, signature string) error {
+	if selector != "" {
+		expected := FunctionSignature(strings.Join(strings.Fields(signature), ""))
+		if !strings.EqualFold(strings.TrimPrefix(selector, "0x"), expected[2:]) {
+			return fmt.Errorf("selector %s does not match signature %s (%s)", selector, signature, expected)
+		}
+	}
+	r.Add(signature)
+	return nil
+}
+
+// Lookup 返回选择器对应的所有签名
+func (r *SelectorRegistry) Lookup(selector []byte) []string {
+	var key [4]byte
+	copy(key[:], selector)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sigs := append([]string(nil), r.sigs[key]...)
+	sort.Strings(sigs)
+	return sigs
+}
+
+// Decode 按选择器找到签名并解析调用数据
+// 只有重新编码后与原数据一致才算匹配, 多个签名都匹配时返回 *SelectorCollisionError
+func (r *SelectorRegistry) Decode(data []byte) (*SelectorMatch, error) {
+	if len(data) < 4 {
+		return nil, errors.New("invalid input data - shorter than method selector")
+	}
+	sigs := r.Lookup(data[:4])
+	if len(sigs) == 0 {
+		return nil, fmt.Errorf("selector %s not found", hexutil.Encode(data[:4]))
+	}
+	var matches []SelectorMatch
+	for _, sig := range sigs {
+		i := strings.Index(sig, "(")
+		args, err := buildArgumentsFromExpr(sig[i:])
+		if err != nil {
+			continue
+		}
+		values, err := args.UnpackValues(data[4:])
+		if err != nil {
+			continue
+		}
+		packed, err := args.Pack(values...)
+		if err != nil || !bytes.Equal(packed, data[4:]) {
+			continue
+		}
+		matches = append(matches, SelectorMatch{Method: sig[:i], Signature: sig, Values: values})
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("selector %s: no signature matches input data", hexutil.Encode(data[:4]))
+	case 1:
+		return &matches[0], nil
+	}
+	return nil, &SelectorCollisionError{Selector: hexutil.Encode(data[:4]), Matches: matches}
+}
@@ -0,0 +1,92 @@
+package laukit
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/laukkw/kwstart/errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeInputData(t *testing.T) {
+	token := MustParseABI(multicallTestABI)
+	data, err := EncodeInputData(token, "balanceOf", common.HexToAddress("0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, args, err := DecodeInputData(token, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "balanceOf" || args["owner"] != common.HexToAddress("0x8c43fbebaa2ded5a50c10766b0f03a151f2bbf17") {
+		t.Fatalf("unexpected decoded input %s %v", name, args)
+	}
+	if _, _, err := DecodeInputData(token, MustDecodeString("deadbeef")); err == nil {
+		t.Fatal("expected unknown selector error")
+	}
+}
+
+func TestSelectorRegistry(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "signatures.txt")
+	content := strings.Join([]string{
+		"# 4byte dump",
+		"0xa9059cbb transfer(address,uint256)",
+		"095ea7b3,approve(address,uint256)",
+		// 与 transfer 选择器相同的已知碰撞签名
+		"0xa9059cbb many_msg_babbage(bytes1)",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	registry, err := LoadSelectorFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := append(MustDecodeString("a9059cbb"), MustDecodeString(AddressPadding("0x487ee5d805b3c95eb23055dc92aad29a89961f17")+AddressPadding("01"))...)
+	match, err := registry.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.Method != "transfer" || match.Values[1].(*big.Int).Int64() != 1 {
+		t.Fatalf("unexpected match %+v", match)
+	}
+
+	if len(registry.Lookup(MustDecodeString("a9059cbb"))) != 2 {
+		t.Fatalf("unexpected signatures %v", registry.Lookup(MustDecodeString("a9059cbb")))
+	}
+
+	// 选择器与签名不一致
+	if err := registry.Load(strings.NewReader(`{"0x00000000": ["collide(uint256)"]}`)); err == nil {
+		t.Fatal("expected selector mismatch error")
+	}
+	for _, dump := range []string{
+		`[{"hex_signature": "0x00000000", "text_signature": "collide(uint256)"}]`,
+		"0x00000000 collide(uint256)",
+		"00000000,collide(uint256)",
+	} {
+		if err := registry.Load(strings.NewReader(dump)); err == nil {
+			t.Fatalf("%s: expected selector mismatch error", dump)
+		}
+	}
+	if len(registry.Lookup(MustDecodeString("00000000"))) != 0 {
+		t.Fatal("mismatched signature should not be registered")
+	}
+	if err := registry.Load(strings.NewReader(`[{"hex_signature": "0x095ea7b3", "text_signature": "approve(address,uint256)"}]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// burn(uint256) 与 collate_propagate_storage(bytes16) 的选择器都是 0x42966c68,
+	// 一个 32 字节参数两个签名都能解析, 需要报告碰撞
+	registry.Add("burn(uint256)")
+	if err := registry.Load(strings.NewReader(`{"0x42966c68": ["collate_propagate_storage(bytes16)"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	var collision *SelectorCollisionError
+	if _, err := registry.Decode(MustDecodeString("42966c6801" + strings.Repeat("00", 31))); !errors.As(err, &collision) || len(collision.Matches) != 2 {
+		t.Fatalf("expected collision error, got %v", err)
+	}
+}
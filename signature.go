@@ -0,0 +1,123 @@
+package laukit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"strings"
+)
+
+// ERC1271MagicValue isValidSignature(bytes32,bytes) 验证通过时返回的值
+var ERC1271MagicValue = [4]byte{0x16, 0x26, 0xba, 0x7e}
+
+var erc1271ABI = MustParseHumanABI(
+	"function isValidSignature(bytes32 hash, bytes signature) view returns (bytes4 magicValue)",
+)
+
+// PersonalMessageHash 返回 EIP-191 personal_sign 的摘要 keccak256("\x19Ethereum Signed Message:\n" ‖ len(message) ‖ message)
+func PersonalMessageHash(message []byte) common.Hash {
+	return common.BytesToHash(accounts.TextHash(message))
+}
+
+// SignPersonalMessage 按 personal_sign 签名, 返回 65 字节的 r ‖ s ‖ v, v 为 27 或 28
+func (e *Eauth) SignPersonalMessage(ctx context.Context, message []byte) ([]byte, error) {
+	signer, err := e.signer()
+	if err != nil {
+		return nil, fmt.Errorf("sign personal message: %w", err)
+	}
+	return signer.SignPersonalMessage(ctx, message)
+}
+
+// ParseSignature 解析 hex 签名, 可以带 0x 前缀, 支持 65 字节和 EIP-2098 的 64 字节格式
+// 返回 65 字节的 r ‖ s ‖ v, v 为 27 或 28
+func ParseSignature(sig string) ([]byte, error) {
+	sig = strings.TrimSpace(sig)
+	if !strings.HasPrefix(sig, "0x") && !strings.HasPrefix(sig, "0X") {
+		sig = "0x" + sig
+	}
+	raw, err := hexutil.Decode(sig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	normalized, err := normalizeSignature(raw)
+	if err != nil {
+		return nil, err
+	}
+	normalized[crypto.RecoveryIDOffset] += 27
+	return normalized, nil
+}
+
+// RecoverPersonalMessage 从 personal_sign 签名恢复签名地址
+// v 可以是 0/1 或 27/28, 也可以是 EIP-2098 的 64 字节签名
+func RecoverPersonalMessage(message []byte, sig []byte) (common.Address, error) {
+	return recoverHashSigner(accounts.TextHash(message), sig)
+}
+
+// VerifySignature 检查 sig 是否为 addr 对 message 的 personal_sign 签名, 只支持 EOA
+// 合约钱包使用 EclVerifySignature
+func VerifySignature(addr common.Address, message []byte, sig []byte) bool {
+	signer, err := RecoverPersonalMessage(message, sig)
+	return err == nil && signer == addr
+}
+
+// EclIsValidSignature 调用合约的 EIP-1271 isValidSignature(hash, sig)
+// 合约 revert 或返回值不是 ERC1271MagicValue 时返回 false, 限流等节点错误原样返回
+func EclIsValidSignature(ctx context.Context, ecl *Ecl, contract common.Address, hash common.Hash, sig []byte) (bool, error) {
+	input, err := EncodeInputData(erc1271ABI, "isValidSignature", hash, sig)
+	if err != nil {
+		return false, err
+	}
+	output, err := ecl.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: input}, nil)
+	if err != nil {
+		if IsRevertError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("erc1271 %s isValidSignature error: %w", contract.Hex(), err)
+	}
+	// 部分钱包返回的数据不足 32 字节, 只比较前 4 字节
+	return len(output) >= 4 && bytes.Equal(output[:4], ERC1271MagicValue[:]), nil
+}
+
+// EclVerifySignature 检查 sig 是否为 addr 对 message 的 personal_sign 签名
+// addr 是合约时按 EIP-1271 验证, 否则按 ECDSA 恢复地址比较
+func EclVerifySignature(ctx context.Context, ecl *Ecl, addr common.Address, message []byte, sig []byte) (bool, error) {
+	if VerifySignature(addr, message, sig) {
+		return true, nil
+	}
+	code, err := ecl.CodeAt(ctx, addr, nil)
+	if err != nil {
+		return false, err
+	}
+	if len(code) == 0 {
+		return false, nil
+	}
+	return EclIsValidSignature(ctx, ecl, addr, PersonalMessageHash(message), sig)
+}
+
+// normalizeSignature 返回 65 字节的 r ‖ s ‖ v, v 为 0 或 1
+// EIP-2098: 64 字节签名的 s 最高位为 v
+func normalizeSignature(sig []byte) ([]byte, error) {
+	normalized := make([]byte, crypto.SignatureLength)
+	switch len(sig) {
+	case crypto.SignatureLength:
+		copy(normalized, sig)
+		if normalized[crypto.RecoveryIDOffset] >= 27 {
+			normalized[crypto.RecoveryIDOffset] -= 27
+		}
+		if normalized[crypto.RecoveryIDOffset] > 1 {
+			return nil, fmt.Errorf("invalid signature recovery id %d", sig[crypto.RecoveryIDOffset])
+		}
+	case 64:
+		copy(normalized, sig)
+		normalized[32] &= 0x7f
+		normalized[crypto.RecoveryIDOffset] = sig[32] >> 7
+	default:
+		return nil, fmt.Errorf("invalid signature length %d", len(sig))
+	}
+	return normalized, nil
+}
@@ -0,0 +1,136 @@
+package laukit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"testing"
+)
+
+func TestPersonalMessage(t *testing.T) {
+	ctx := context.Background()
+	auth, _ := NewEAuth(WithPrivateKey("0x1234567890123456789012345678901234567890123456789012345678901234"))
+	addr := crypto.PubkeyToAddress(auth.Private.PublicKey)
+	message := []byte("Hello World")
+
+	if hash := PersonalMessageHash(message); hash.Hex() != "0xa1de988600a42c4b4ab089b619297c17d53cffae5d5120d82d8a92d0bb3b78f2" {
+		t.Fatalf("unexpected message hash %s", hash.Hex())
+	}
+	sig, err := auth.SignPersonalMessage(ctx, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig[64] != 27 && sig[64] != 28 {
+		t.Fatalf("unexpected v %d", sig[64])
+	}
+	if signer, err := RecoverPersonalMessage(message, sig); err != nil || signer != addr {
+		t.Fatalf("unexpected signer %s %v", signer.Hex(), err)
+	}
+
+	// v 为 0/1
+	raw := common.CopyBytes(sig)
+	raw[64] -= 27
+	// EIP-2098: yParity 放在 s 的最高位
+	compact := common.CopyBytes(sig[:64])
+	compact[32] |= (sig[64] - 27) << 7
+	for _, s := range [][]byte{raw, compact} {
+		if !VerifySignature(addr, message, s) {
+			t.Fatalf("signature %x should be valid", s)
+		}
+	}
+	for _, s := range []string{hexutil.Encode(sig), hexutil.Encode(compact)[2:]} {
+		parsed, err := ParseSignature(s)
+		if err != nil || hexutil.Encode(parsed) != hexutil.Encode(sig) {
+			t.Fatalf("unexpected parsed signature %x %v", parsed, err)
+		}
+	}
+
+	if VerifySignature(addr, []byte("Hello World!"), sig) || VerifySignature(common.HexToAddress("0x01"), message, sig) {
+		t.Fatal("signature should be invalid")
+	}
+	raw[64] = 5
+	if _, err := RecoverPersonalMessage(message, raw); err == nil {
+		t.Fatal("expected invalid recovery id")
+	}
+	if _, err := ParseSignature("0x1234"); err == nil {
+		t.Fatal("expected invalid signature length")
+	}
+}
+
+func TestEclVerifySignature(t *testing.T) {
+	ctx := context.Background()
+	owner, _ := NewEAuth(WithPrivateKey("0x1234567890123456789012345678901234567890123456789012345678901234"))
+	message := []byte("login challenge")
+	sig, _ := owner.SignPersonalMessage(ctx, message)
+	wallet := common.HexToAddress("0xaa")
+	eoa := crypto.PubkeyToAddress(owner.Private.PublicKey)
+
+	stub := newStubRPC(t, map[string]stubHandler{
+		// 合约钱包: 由 owner 签名即有效
+		"eth_call": stubContract(t, erc1271ABI, map[string]stubContractMethod{
+			"isValidSignature": func(to common.Address, args []interface{}) ([]interface{}, error) {
+				hash := args[0].([32]byte)
+				signer, err := recoverHashSigner(hash[:], args[1].([]byte))
+				if err != nil || signer != eoa {
+					return []interface{}{[4]byte{0xff, 0xff, 0xff, 0xff}}, nil
+				}
+				return []interface{}{ERC1271MagicValue}, nil
+			},
+		}),
+		"eth_getCode": func(params []json.RawMessage) (interface{}, error) {
+			var addr common.Address
+			_ = json.Unmarshal(params[0], &addr)
+			if addr == wallet {
+				return "0x6080", nil
+			}
+			return "0x", nil
+		},
+	})
+	ecl := stub.ecl(t)
+
+	for _, c := range []struct {
+		addr    common.Address
+		message []byte
+		valid   bool
+	}{
+		{eoa, message, true},
+		{wallet, message, true},
+		{wallet, []byte("other"), false},
+		{common.HexToAddress("0xbb"), message, false},
+	} {
+		valid, err := EclVerifySignature(ctx, ecl, c.addr, c.message, sig)
+		if err != nil || valid != c.valid {
+			t.Fatalf("%s %q: got %v %v, want %v", c.addr.Hex(), c.message, valid, err, c.valid)
+		}
+	}
+	if stub.count("eth_call") != 2 {
+		t.Fatalf("unexpected eth_call count %d", stub.count("eth_call"))
+	}
+}
+
+func TestEclIsValidSignatureErrors(t *testing.T) {
+	ctx := context.Background()
+	wallet := common.HexToAddress("0xaa")
+	stub := newStubRPC(t, map[string]stubHandler{
+		"eth_getCode": func([]json.RawMessage) (interface{}, error) { return "0x6080", nil },
+		"eth_call": func([]json.RawMessage) (interface{}, error) {
+			return nil, &stubError{Code: 3, Message: "execution reverted", Data: "0x"}
+		},
+	})
+	ecl := stub.ecl(t)
+	if valid, err := EclIsValidSignature(ctx, ecl, wallet, common.Hash{}, []byte{1}); err != nil || valid {
+		t.Fatalf("revert should be invalid signature, got %v %v", valid, err)
+	}
+	// 限流不能当作签名无效
+	stub.handle("eth_call", func([]json.RawMessage) (interface{}, error) {
+		return nil, &stubError{Code: -32005, Message: "request rate limited"}
+	})
+	if _, err := EclIsValidSignature(ctx, ecl, wallet, common.Hash{}, []byte{1}); err == nil {
+		t.Fatal("expected rate limit error")
+	}
+	if _, err := EclVerifySignature(ctx, ecl, wallet, []byte("login challenge"), make([]byte, 65)); err == nil {
+		t.Fatal("expected rate limit error")
+	}
+}
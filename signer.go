@@ -0,0 +1,256 @@
+package laukit
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/laukkw/kwstart/errors"
+	"math/big"
+	"reflect"
+)
+
+var (
+	ErrSignerNotSet = errors.New("signer or private key is not set")
+	// ErrSignHashNotSupported 远程签名服务 (Clef / 节点) 不提供对任意 hash 签名的接口
+	ErrSignHashNotSupported = errors.New("remote signer does not support signing raw hash")
+)
+
+// Signer 签名账户, 私钥可以在本地 (KeySigner) 也可以在远程 (RemoteSigner)
+type Signer interface {
+	Address() common.Address
+	// SignTx 按 chainId 的最新规则签名交易
+	SignTx(ctx context.Context, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error)
+	// SignHash 返回 65 字节的 r ‖ s ‖ v, v 为 0 或 1, 与 crypto.Sign 一致
+	SignHash(ctx context.Context, hash common.Hash) ([]byte, error)
+	// SignTypedData 返回 65 字节的 r ‖ s ‖ v, v 为 27 或 28, 与 eth_signTypedData_v4 一致
+	SignTypedData(ctx context.Context, typedData *TypedData) ([]byte, error)
+	// SignPersonalMessage EIP-191 personal_sign, v 为 27 或 28
+	SignPersonalMessage(ctx context.Context, message []byte) ([]byte, error)
+}
+
+// KeySigner 用内存中的私钥签名
+type KeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func NewKeySigner(key *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}
+}
+
+// NewKeystoreSigner 解密 keystore 文件, 返回使用其私钥的 KeySigner
+func NewKeystoreSigner(path, passphrase string) (*KeySigner, error) {
+	key, err := LoadKeystore(path, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySigner(key), nil
+}
+
+func (s *KeySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *KeySigner) SignTx(_ context.Context, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainId), s.key)
+}
+
+func (s *KeySigner) SignHash(_ context.Context, hash common.Hash) ([]byte, error) {
+	return crypto.Sign(hash.Bytes(), s.key)
+}
+
+func (s *KeySigner) SignTypedData(ctx context.Context, typedData *TypedData) ([]byte, error) {
+	hash, err := TypedDataHash(typedData)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := s.SignHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
+
+func (s *KeySigner) SignPersonalMessage(ctx context.Context, message []byte) ([]byte, error) {
+	sig, err := s.SignHash(ctx, PersonalMessageHash(message))
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
+
+// Signer 返回第 index 个账户的 KeySigner
+func (w *Wallet) Signer(index uint32) (*KeySigner, error) {
+	key, err := w.PrivateKey(index)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySigner(key), nil
+}
+
+// RemoteSigner 通过 JSON-RPC 请求远程签名
+// Clef 使用 account_signTransaction / account_signTypedData, 节点使用 eth_signTransaction / eth_signTypedData_v4
+type RemoteSigner struct {
+	Rpc     *rpc.Client
+	Account common.Address
+	Clef    bool
+}
+
+// NewRemoteSigner 使用节点 (geth --unlock 等) 的 eth_sign* 接口
+func NewRemoteSigner(client *rpc.Client, account common.Address) *RemoteSigner {
+	return &RemoteSigner{Rpc: client, Account: account}
+}
+
+// NewClefSigner 使用 Clef 的 account_* 接口
+func NewClefSigner(client *rpc.Client, account common.Address) *RemoteSigner {
+	return &RemoteSigner{Rpc: client, Account: account, Clef: true}
+}
+
+func (s *RemoteSigner) Address() common.Address {
+	return s.Account
+}
+
+func (s *RemoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainId *big.Int) (*types.Transaction, error) {
+	args := apitypes.SendTxArgs{
+		From:  common.NewMixedcaseAddress(s.Account),
+		Gas:   hexutil.Uint64(tx.Gas()),
+		Value: hexutil.Big(*tx.Value()),
+		Nonce: hexutil.Uint64(tx.Nonce()),
+	}
+	if tx.To() != nil {
+		to := common.NewMixedcaseAddress(*tx.To())
+		args.To = &to
+	}
+	data := hexutil.Bytes(tx.Data())
+	args.Data = &data
+	if chainId != nil {
+		args.ChainID = (*hexutil.Big)(chainId)
+	}
+	switch tx.Type() {
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+	case types.AccessListTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+	default:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	}
+
+	method := "eth_signTransaction"
+	if s.Clef {
+		method = "account_signTransaction"
+	}
+	var result struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := s.Rpc.CallContext(ctx, &result, method, &args); err != nil {
+		return nil, fmt.Errorf("%s error: %w", method, err)
+	}
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(result.Raw); err != nil {
+		return nil, fmt.Errorf("%s decode error: %w", method, err)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(signed.ChainId()), signed)
+	if err != nil {
+		return nil, fmt.Errorf("%s error: %w", method, err)
+	}
+	if from != s.Account || !sameTxRequest(signed, tx, chainId) {
+		return nil, fmt.Errorf("%s error: signed transaction does not match request", method)
+	}
+	return signed, nil
+}
+
+// normalizeAccessList 空的 access list 与 nil 等价, 没有 storage key 的条目同理
+func normalizeAccessList(list types.AccessList) types.AccessList {
+	if len(list) == 0 {
+		return nil
+	}
+	normalized := make(types.AccessList, len(list))
+	for i, tuple := range list {
+		normalized[i].Address = tuple.Address
+		if len(tuple.StorageKeys) > 0 {
+			normalized[i].StorageKeys = tuple.StorageKeys
+		}
+	}
+	return normalized
+}
+
+// sameTxRequest 远程签名后的交易内容是否与请求一致, 防止签名服务改写收款地址、金额或手续费
+func sameTxRequest(signed, tx *types.Transaction, chainId *big.Int) bool {
+	if signed.Type() != tx.Type() || signed.Nonce() != tx.Nonce() || signed.Gas() != tx.Gas() ||
+		signed.Value().Cmp(tx.Value()) != 0 || !bytes.Equal(signed.Data(), tx.Data()) ||
+		signed.GasPrice().Cmp(tx.GasPrice()) != 0 || signed.GasTipCap().Cmp(tx.GasTipCap()) != 0 ||
+		signed.GasFeeCap().Cmp(tx.GasFeeCap()) != 0 {
+		return false
+	}
+	if (signed.To() == nil) != (tx.To() == nil) || (tx.To() != nil && *signed.To() != *tx.To()) {
+		return false
+	}
+	if !reflect.DeepEqual(normalizeAccessList(signed.AccessList()), normalizeAccessList(tx.AccessList())) {
+		return false
+	}
+	return chainId == nil || signed.ChainId().Cmp(chainId) == 0
+}
+
+func (s *RemoteSigner) SignHash(context.Context, common.Hash) ([]byte, error) {
+	return nil, ErrSignHashNotSupported
+}
+
+func (s *RemoteSigner) SignTypedData(ctx context.Context, typedData *TypedData) ([]byte, error) {
+	typedData, err := completeTypedData(typedData)
+	if err != nil {
+		return nil, err
+	}
+	method := "eth_signTypedData_v4"
+	if s.Clef {
+		method = "account_signTypedData"
+	}
+	var sig hexutil.Bytes
+	if err := s.Rpc.CallContext(ctx, &sig, method, s.Account, typedData); err != nil {
+		return nil, fmt.Errorf("%s error: %w", method, err)
+	}
+	return remoteSignature(method, sig)
+}
+
+// SignPersonalMessage 节点使用 eth_sign, Clef 使用 account_signData (text/plain), 二者都会加上 EIP-191 前缀
+func (s *RemoteSigner) SignPersonalMessage(ctx context.Context, message []byte) ([]byte, error) {
+	var (
+		sig    hexutil.Bytes
+		err    error
+		method = "eth_sign"
+	)
+	if s.Clef {
+		method = "account_signData"
+		err = s.Rpc.CallContext(ctx, &sig, method, "text/plain", s.Account, hexutil.Bytes(message))
+	} else {
+		err = s.Rpc.CallContext(ctx, &sig, method, s.Account, hexutil.Bytes(message))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s error: %w", method, err)
+	}
+	return remoteSignature(method, sig)
+}
+
+// remoteSignature 检查签名长度并把 v 统一为 27 或 28
+func remoteSignature(method string, sig []byte) ([]byte, error) {
+	if len(sig) != crypto.SignatureLength {
+		return nil, fmt.Errorf("%s error: invalid signature length %d", method, len(sig))
+	}
+	if sig[crypto.RecoveryIDOffset] < 27 {
+		sig[crypto.RecoveryIDOffset] += 27
+	}
+	return sig, nil
+}
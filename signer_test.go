@@ -0,0 +1,263 @@
+package laukit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"math/big"
+	"path/filepath"
+	"testing"
+)
+
+// stubSignerRPC 模拟 Clef / 节点的签名接口, 用 key 签名
+func stubSignerRPC(t *testing.T, key *ecdsa.PrivateKey, prefix string) *stubRPC {
+	signer := NewKeySigner(key)
+	signTypedData := func(params []json.RawMessage) (interface{}, error) {
+		var addr common.MixedcaseAddress
+		_ = json.Unmarshal(params[0], &addr)
+		if addr.Address() != signer.Address() {
+			return nil, &stubError{Code: -32000, Message: "unknown account"}
+		}
+		typedData, err := ParseTypedData(params[1])
+		if err != nil {
+			return nil, err
+		}
+		sig, err := signer.SignTypedData(context.Background(), typedData)
+		return hexutil.Bytes(sig), err
+	}
+	signMessage := func(addr, data json.RawMessage) (interface{}, error) {
+		var account common.Address
+		var message hexutil.Bytes
+		_ = json.Unmarshal(addr, &account)
+		if err := json.Unmarshal(data, &message); err != nil || account != signer.Address() {
+			return nil, &stubError{Code: -32000, Message: "invalid request"}
+		}
+		sig, err := signer.SignPersonalMessage(context.Background(), message)
+		return hexutil.Bytes(sig), err
+	}
+	typedDataMethod, signMethod := "eth_signTypedData_v4", "eth_sign"
+	if prefix == "account" {
+		typedDataMethod, signMethod = "account_signTypedData", "account_signData"
+	}
+	return newStubRPC(t, map[string]stubHandler{
+		prefix + "_signTransaction": func(params []json.RawMessage) (interface{}, error) {
+			var args apitypes.SendTxArgs
+			if err := json.Unmarshal(params[0], &args); err != nil {
+				return nil, err
+			}
+			if args.From.Address() != signer.Address() {
+				return nil, &stubError{Code: -32000, Message: "unknown account"}
+			}
+			tx, err := signer.SignTx(context.Background(), args.ToTransaction(), (*big.Int)(args.ChainID))
+			if err != nil {
+				return nil, err
+			}
+			raw, _ := tx.MarshalBinary()
+			return map[string]interface{}{"raw": hexutil.Bytes(raw), "tx": tx}, nil
+		},
+		typedDataMethod: signTypedData,
+		signMethod: func(params []json.RawMessage) (interface{}, error) {
+			if prefix == "account" {
+				return signMessage(params[1], params[2])
+			}
+			return signMessage(params[0], params[1])
+		},
+	})
+}
+
+func TestRemoteSigner(t *testing.T) {
+	ctx := context.Background()
+	key, _ := crypto.ToECDSA(crypto.Keccak256([]byte("cow")))
+	local := NewKeySigner(key)
+	to := common.HexToAddress("0x01")
+	typedData, _ := ParseTypedData([]byte(eip712MailJSON))
+	expected, _ := local.SignTypedData(ctx, typedData)
+
+	for _, prefix := range []string{"eth", "account"} {
+		ecl := stubSignerRPC(t, key, prefix).ecl(t)
+		remote := NewRemoteSigner(ecl.Rpc, local.Address())
+		remote.Clef = prefix == "account"
+
+		for _, tx := range []*types.Transaction{
+			types.NewTx(&types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1), Gas: 21000, To: &to, Value: big.NewInt(2)}),
+			types.NewTx(&types.DynamicFeeTx{Nonce: 2, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(3), Gas: 50000, To: &to, Data: []byte{1, 2}}),
+		} {
+			signed, err := remote.SignTx(ctx, tx, big.NewInt(1))
+			if err != nil {
+				t.Fatalf("%s: %v", prefix, err)
+			}
+			from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), signed)
+			if err != nil || from != local.Address() || signed.Type() != tx.Type() || signed.Gas() != tx.Gas() {
+				t.Fatalf("%s: unexpected signed transaction %s %v", prefix, from.Hex(), err)
+			}
+		}
+
+		sig, err := remote.SignTypedData(ctx, typedData)
+		if err != nil || hexutil.Encode(sig) != hexutil.Encode(expected) {
+			t.Fatalf("%s: unexpected typed data signature %x %v", prefix, sig, err)
+		}
+		// 没有 EIP712Domain 定义时发送补全后的副本, 不修改调用方的 typedData
+		withoutDomain := *typedData
+		withoutDomain.Types = apitypes.Types{"Person": typedData.Types["Person"], "Mail": typedData.Types["Mail"]}
+		sig, err = remote.SignTypedData(ctx, &withoutDomain)
+		if _, ok := withoutDomain.Types[eip712DomainType]; ok || err != nil || hexutil.Encode(sig) != hexutil.Encode(expected) {
+			t.Fatalf("%s: unexpected typed data signature without domain type %x %v", prefix, sig, err)
+		}
+		sig, err = remote.SignPersonalMessage(ctx, []byte("hello"))
+		if signer, _ := RecoverPersonalMessage([]byte("hello"), sig); err != nil || signer != local.Address() {
+			t.Fatalf("%s: unexpected personal signature %x %v", prefix, sig, err)
+		}
+		if _, err := remote.SignHash(ctx, common.Hash{}); err != ErrSignHashNotSupported {
+			t.Fatalf("%s: expected sign hash error, got %v", prefix, err)
+		}
+
+		// 远程服务没有这个账户
+		other := NewRemoteSigner(ecl.Rpc, to)
+		other.Clef = remote.Clef
+		if _, err := other.SignTypedData(ctx, typedData); err == nil {
+			t.Fatalf("%s: expected unknown account error", prefix)
+		}
+	}
+}
+
+// 签名服务改写了交易内容时 SignTx 返回错误
+func TestRemoteSignerTampered(t *testing.T) {
+	ctx := context.Background()
+	key, _ := crypto.ToECDSA(crypto.Keccak256([]byte("cow")))
+	local := NewKeySigner(key)
+	to := common.HexToAddress("0x01")
+	tx := types.NewTx(&types.DynamicFeeTx{Nonce: 2, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(3), Gas: 50000, To: &to, Value: big.NewInt(5), Data: []byte{1, 2}})
+
+	for name, tamper := range map[string]func(args *apitypes.SendTxArgs){
+		"none": func(*apitypes.SendTxArgs) {},
+		"to": func(args *apitypes.SendTxArgs) {
+			other := common.NewMixedcaseAddress(common.HexToAddress("0x02"))
+			args.To = &other
+		},
+		"value": func(args *apitypes.SendTxArgs) { args.Value = hexutil.Big(*big.NewInt(6)) },
+		"data":  func(args *apitypes.SendTxArgs) { data := hexutil.Bytes{1}; args.Data = &data },
+		"gas":   func(args *apitypes.SendTxArgs) { args.Gas = 60000 },
+		"fee":   func(args *apitypes.SendTxArgs) { args.MaxFeePerGas = (*hexutil.Big)(big.NewInt(4)) },
+		"tip":   func(args *apitypes.SendTxArgs) { args.MaxPriorityFeePerGas = (*hexutil.Big)(big.NewInt(2)) },
+		"type": func(args *apitypes.SendTxArgs) {
+			args.GasPrice, args.MaxFeePerGas, args.MaxPriorityFeePerGas, args.AccessList = (*hexutil.Big)(big.NewInt(3)), nil, nil, nil
+		},
+		"chainId": func(args *apitypes.SendTxArgs) { args.ChainID = (*hexutil.Big)(big.NewInt(5)) },
+		"accessList": func(args *apitypes.SendTxArgs) {
+			args.AccessList = &types.AccessList{{Address: common.HexToAddress("0x03"), StorageKeys: []common.Hash{{1}}}}
+		},
+	} {
+		stub := stubSignerRPC(t, key, "eth")
+		stub.handle("eth_signTransaction", func(params []json.RawMessage) (interface{}, error) {
+			var args apitypes.SendTxArgs
+			if err := json.Unmarshal(params[0], &args); err != nil {
+				return nil, err
+			}
+			tamper(&args)
+			signed, err := local.SignTx(ctx, args.ToTransaction(), (*big.Int)(args.ChainID))
+			if err != nil {
+				return nil, err
+			}
+			raw, _ := signed.MarshalBinary()
+			return map[string]interface{}{"raw": hexutil.Bytes(raw)}, nil
+		})
+		remote := NewRemoteSigner(stub.ecl(t).Rpc, local.Address())
+		_, err := remote.SignTx(ctx, tx, big.NewInt(1))
+		if name == "none" && err != nil {
+			t.Fatal(err)
+		}
+		if name != "none" && err == nil {
+			t.Fatalf("%s: expected mismatch error", name)
+		}
+	}
+}
+
+func TestKeystoreSigner(t *testing.T) {
+	useLightKeystore(t)
+	ctx := context.Background()
+	key, _ := crypto.HexToECDSA(keystoreTestPrivateKey)
+	path := filepath.Join(t.TempDir(), "key.json")
+	if err := SaveKeystore(path, key, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewKeystoreSigner(path, "wrong"); err == nil {
+		t.Fatal("expected decrypt error")
+	}
+	signer, err := NewKeystoreSigner(path, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.Address() != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("unexpected keystore signer address %s", signer.Address().Hex())
+	}
+	to := common.HexToAddress("0x01")
+	signed, err := signer.SignTx(ctx, types.NewTx(&types.DynamicFeeTx{Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &to}), big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), signed); err != nil || from != signer.Address() {
+		t.Fatalf("unexpected sender %s %v", from.Hex(), err)
+	}
+	sig, err := signer.SignPersonalMessage(ctx, []byte("hello"))
+	if recovered, _ := RecoverPersonalMessage([]byte("hello"), sig); err != nil || recovered != signer.Address() {
+		t.Fatalf("unexpected personal signature %x %v", sig, err)
+	}
+}
+
+func TestEauthSigner(t *testing.T) {
+	ctx := context.Background()
+	key, _ := crypto.ToECDSA(crypto.Keccak256([]byte("cow")))
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	stub := stubSignerRPC(t, key, "eth")
+	stub.handle("eth_getTransactionCount", func([]json.RawMessage) (interface{}, error) { return "0x5", nil })
+	stub.handle("eth_gasPrice", func([]json.RawMessage) (interface{}, error) { return "0x2", nil })
+	stub.handle("eth_maxPriorityFeePerGas", func([]json.RawMessage) (interface{}, error) { return "0x1", nil })
+	ecl := stub.ecl(t)
+
+	auth, _ := NewEAuth(WithEcl(ecl))
+	if _, err := auth.NewTransactor(ctx); err != ErrSignerNotSet {
+		t.Fatalf("expected signer not set, got %v", err)
+	}
+	auth, err := NewEAuth(WithEcl(ecl), WithSigner(NewRemoteSigner(ecl.Rpc, from)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := auth.NewTransactor(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.From != from || opts.Nonce.Uint64() != 5 || opts.Signer == nil {
+		t.Fatalf("unexpected transact opts %+v", opts)
+	}
+	to := common.HexToAddress("0x01")
+	signed, err := opts.Signer(from, types.NewTx(&types.LegacyTx{Nonce: 5, GasPrice: big.NewInt(2), Gas: 21000, To: &to}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender, _ := types.Sender(types.LatestSignerForChainID(ecl.ChainId), signed); sender != from {
+		t.Fatalf("unexpected sender %s", sender.Hex())
+	}
+	if _, err := opts.Signer(to, signed); err == nil {
+		t.Fatal("expected not authorized error")
+	}
+
+	opts, err = auth.NewTransactorNotPrivateKey(ctx, from.Hex())
+	if err != nil || opts.Signer == nil {
+		t.Fatalf("expected signer for configured account %v", err)
+	}
+	if opts, _ = auth.NewTransactorNotPrivateKey(ctx, to.Hex()); opts.Signer != nil {
+		t.Fatal("unexpected signer for other account")
+	}
+
+	// HD 钱包账户
+	w, _ := NewWallet("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	signer, err := w.Signer(0)
+	if err != nil || signer.Address() != common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94") {
+		t.Fatalf("unexpected wallet signer %v", err)
+	}
+}
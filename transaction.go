@@ -4,35 +4,57 @@ import (
     "context"
     "fmt"
     "github.com/ethereum/go-ethereum"
+    "github.com/ethereum/go-ethereum/accounts/abi"
     "github.com/ethereum/go-ethereum/common"
     "github.com/ethereum/go-ethereum/core/types"
+    "github.com/ethereum/go-ethereum/rpc"
     "github.com/laukkw/kwstart/errors"
     "math/big"
-    "time"
+    "strings"
 )
 
 type TransactionReq struct {
-    From       common.Address
-    To         common.Address
-    Nonce      *big.Int
-    GasLimit   uint64
-    GasPrice   *big.Int
-    GasTip     *big.Int
-    AccessList types.AccessList
-    ETHValue   *big.Int
-    Data       []byte
+    From        common.Address
+    To          common.Address
+    Nonce       *big.Int
+    GasLimit    uint64
+    GasPrice    *big.Int
+    GasTip      *big.Int
+    AccessList  types.AccessList
+    ETHValue    *big.Int
+    Data        []byte
+    // GasPrice 为空时用 FeeStrategy 计算 GasTip 和 fee cap(GasPrice), FeeStrategy 为空时使用 FeeNormal,
+    // 节点不支持 eth_feeHistory 时退回 legacy GasPrice
+    FeeStrategy FeeStrategy
+    // ErrorABI 不为空时, 估算 gas 失败会按其中的自定义 error 解析 revert 数据
+    ErrorABI    *abi.ABI
 }
 type WaitReceipt func(ctx context.Context) (*types.Receipt, error)
 
 var errorPath = "Transaction package "
 
-func EclNewTransaction(ctx context.Context, ecl *Ecl, req *TransactionReq) (*types.Transaction, error) {
+func EclNewTransaction(ctx context.Context, ecl *Ecl, req *TransactionReq) (tx *types.Transaction, err error) {
     if ecl == nil || req == nil {
         return nil, fmt.Errorf("%s new transaction error: 请求为空", errorPath)
     }
     to := &req.To
 
-    if req.Nonce == nil {
+    if req.Nonce == nil && ecl.Nonces != nil {
+        // 不能用 := 遮蔽返回值 err, 否则下面的 defer 看不到构造失败
+        var nonce uint64
+        nonce, err = ecl.Nonces.Next(ctx, ecl, req.From)
+        if err != nil {
+            return nil, err
+        }
+        req.Nonce = big.NewInt(0).SetUint64(nonce)
+        // 交易没有构造成功时归还 nonce
+        defer func() {
+            if err != nil {
+                ecl.Nonces.Release(ecl, req.From, nonce)
+                req.Nonce = nil
+            }
+        }()
+    } else if req.Nonce == nil {
         nonce, err := ecl.PendingNonceAt(ctx, req.From)
         if err != nil {
             return nil, fmt.Errorf("%s ecl get pending nonce error %w", errorPath, err)
@@ -40,11 +62,29 @@ func EclNewTransaction(ctx context.Context, ecl *Ecl, req *TransactionReq) (*typ
         req.Nonce = big.NewInt(0).SetUint64(nonce)
     }
     if req.GasPrice == nil {
-        gasPrice, err := ecl.SuggestGasPrice(ctx)
-        if err != nil {
-            return nil, fmt.Errorf("%s ecl get gas price error: %w", errorPath, err)
+        // 与 Eauth.NewTransactor 一致: 默认 FeeNormal, 默认策略失败时退回 legacy GasPrice
+        strategy := req.FeeStrategy
+        if strategy == nil {
+            strategy = FeeNormal
+        }
+        tip, feeCap, ferr := strategy.Fees(ctx, ecl)
+        if ferr == nil {
+            // 调用方指定了 GasTip 时, fee cap 中的 tip 换成调用方的
+            if req.GasTip != nil {
+                feeCap = new(big.Int).Add(new(big.Int).Sub(feeCap, tip), req.GasTip)
+            } else {
+                req.GasTip = tip
+            }
+            req.GasPrice = feeCap
+        } else if req.FeeStrategy != nil {
+            return nil, ferr
+        } else {
+            gasPrice, err := ecl.SuggestGasPrice(ctx)
+            if err != nil {
+                return nil, fmt.Errorf("%s ecl get gas price error: %w", errorPath, err)
+            }
+            req.GasPrice = gasPrice
         }
-        req.GasPrice = gasPrice
     }
     if req.GasLimit == 0 {
         callMsg := ethereum.CallMsg{
@@ -58,7 +98,11 @@ func EclNewTransaction(ctx context.Context, ecl *Ecl, req *TransactionReq) (*typ
 
         gasLimit, err := ecl.EstimateGas(ctx, callMsg)
         if err != nil {
-            return nil, fmt.Errorf("%s ecl estimate gas error: %w", errorPath, err)
+            var abis []abi.ABI
+            if req.ErrorABI != nil {
+                abis = append(abis, *req.ErrorABI)
+            }
+            return nil, fmt.Errorf("%s ecl estimate gas error: %w", errorPath, DecodeCallError(err, abis...))
         }
         req.GasLimit = gasLimit
     }
@@ -161,35 +205,36 @@ func EclSendTransaction(ctx context.Context, ecl *Ecl, signTx *types.Transaction
         return EclWaitReceipt(ctx, ecl, signTx.Hash())
     }
 
-    return signTx, waitFn, ecl.SendTransaction(ctx, signTx)
+    err := ecl.SendTransaction(ctx, signTx)
+    if err != nil && ecl.Nonces != nil {
+        eclHandleNonceError(ctx, ecl, signTx, err)
+    }
+    return signTx, waitFn, err
 }
 
-func EclWaitReceipt(ctx context.Context, ecl *Ecl, txHash common.Hash) (*types.Receipt, error) {
-    var clearTimeout context.CancelFunc
-    if _, ok := ctx.Deadline(); !ok {
-        ctx, clearTimeout = context.WithTimeout(ctx, 120*time.Second) // default timeout of 120 seconds
-        defer clearTimeout()
+// eclHandleNonceError 广播失败时维护本地 nonce: nonce too low 时重新同步,
+// 节点明确拒绝的交易归还 nonce. 网络错误时交易可能已经广播, 不做处理
+func eclHandleNonceError(ctx context.Context, ecl *Ecl, signTx *types.Transaction, err error) {
+    from, serr := types.Sender(types.LatestSignerForChainID(ecl.ChainId), signTx)
+    if serr != nil {
+        return
     }
-
-    for {
-        select {
-        case <-ctx.Done():
-            if err := ctx.Err(); err != nil {
-                return nil, fmt.Errorf("ethwallet, WaitReceipt for %v: %w", txHash, err)
-            }
-        default:
-        }
-
-        receipt, err := ecl.TransactionReceipt(ctx, txHash)
-        if err != nil && !errors.Is(err, ethereum.NotFound) {
-            return nil, err
-        }
-
-        if receipt != nil {
-            return receipt, nil
-        }
-
-        time.Sleep(1 * time.Second)
+    if IsNonceTooLow(err) {
+        _ = ecl.Nonces.Resync(ctx, ecl, from)
+        return
+    }
+    var rpcErr rpc.Error
+    if errors.As(err, &rpcErr) && !strings.Contains(err.Error(), "already known") {
+        ecl.Nonces.Release(ecl, from, signTx.Nonce())
     }
+}
+
+// EclWaitReceipt 等待交易被打包, 需要确认数或重组检测时使用 ReceiptWaiter
+func EclWaitReceipt(ctx context.Context, ecl *Ecl, txHash common.Hash) (*types.Receipt, error) {
+    return eclWaitAnyReceipt(ctx, ecl, txHash)
+}
 
+// eclWaitAnyReceipt 等待多个交易中任意一个被打包, 用于相同 nonce 的替换交易
+func eclWaitAnyReceipt(ctx context.Context, ecl *Ecl, txHashes ...common.Hash) (*types.Receipt, error) {
+    return NewReceiptWaiter(ecl, 1).Wait(ctx, txHashes...)
 }
@@ -1 +1,185 @@
 package laukit
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/laukkw/kwstart/errors"
+	"github.com/tyler-smith/go-bip39"
+	"math/big"
+	"strings"
+)
+
+// DefaultHDBasePath BIP-44 以太坊路径, 第 i 个账户为 m/44'/60'/0'/0/i
+const DefaultHDBasePath = "m/44'/60'/0'/0"
+
+var ErrInvalidMnemonic = errors.New("invalid bip39 mnemonic")
+
+// Wallet BIP-39 助记词 + BIP-32 分层确定性钱包
+type Wallet struct {
+	mnemonic string
+	master   hdKey
+	// BasePath 按序号派生时使用的路径前缀, 默认 DefaultHDBasePath
+	BasePath string
+}
+
+// hdKey BIP-32 扩展私钥
+type hdKey struct {
+	key   []byte
+	chain []byte
+}
+
+// NewMnemonic 生成助记词, bits 为熵的位数: 128 (12 个词) 到 256 (24 个词), 必须是 32 的倍数
+func NewMnemonic(bits int) (string, error) {
+	entropy, err := bip39.NewEntropy(bits)
+	if err != nil {
+		return "", err
+	}
+	return bip39.NewMnemonic(entropy)
+}
+
+// ValidateMnemonic 检查单词是否在词表中以及校验和是否正确
+func ValidateMnemonic(mnemonic string) bool {
+	return bip39.IsMnemonicValid(normalizeMnemonic(mnemonic))
+}
+
+// NewWallet 从助记词和可选的密码创建钱包
+func NewWallet(mnemonic, passphrase string) (*Wallet, error) {
+	mnemonic = normalizeMnemonic(mnemonic)
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMnemonic, err)
+	}
+	w, err := NewWalletFromSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	w.mnemonic = mnemonic
+	return w, nil
+}
+
+// NewWalletFromSeed 从 BIP-39 种子创建钱包, 种子长度为 16 到 64 字节
+func NewWalletFromSeed(seed []byte) (*Wallet, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("invalid seed length %d", len(seed))
+	}
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	master := hdKey{key: sum[:32], chain: sum[32:]}
+	if !validHDKey(master.key) {
+		return nil, errors.New("invalid master key, use another seed")
+	}
+	return &Wallet{master: master, BasePath: DefaultHDBasePath}, nil
+}
+
+// Mnemonic 从种子创建的钱包返回空字符串
+func (w *Wallet) Mnemonic() string {
+	return w.mnemonic
+}
+
+// Derive 按路径派生私钥, 例如 m/44'/60'/0'/0/0
+func (w *Wallet) Derive(path string) (*ecdsa.PrivateKey, error) {
+	derivation, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	key := w.master
+	for _, index := range derivation {
+		if key, err = key.child(index); err != nil {
+			return nil, fmt.Errorf("derive %s: %w", path, err)
+		}
+	}
+	return crypto.ToECDSA(key.key)
+}
+
+// PrivateKey 返回 BasePath/index 的私钥
+func (w *Wallet) PrivateKey(index uint32) (*ecdsa.PrivateKey, error) {
+	return w.Derive(w.path(index))
+}
+
+func (w *Wallet) Address(index uint32) (common.Address, error) {
+	key, err := w.PrivateKey(index)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(key.PublicKey), nil
+}
+
+// Addresses 返回从 start 开始的 count 个地址
+func (w *Wallet) Addresses(start, count uint32) ([]common.Address, error) {
+	addresses := make([]common.Address, 0, count)
+	for i := start; i < start+count; i++ {
+		addr, err := w.Address(i)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, addr)
+	}
+	return addresses, nil
+}
+
+// Eauth 返回使用第 index 个账户私钥的 Eauth, opts 用于设置 Ecl 等其它选项
+func (w *Wallet) Eauth(index uint32, opts ...EauthOptions) (*Eauth, error) {
+	key, err := w.PrivateKey(index)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := NewEAuth(opts...)
+	if err != nil {
+		return nil, err
+	}
+	auth.Private = key
+	return auth, nil
+}
+
+func (w *Wallet) path(index uint32) string {
+	base := w.BasePath
+	if base == "" {
+		base = DefaultHDBasePath
+	}
+	return fmt.Sprintf("%s/%d", strings.TrimSuffix(base, "/"), index)
+}
+
+// child BIP-32 私钥派生, index >= 2^31 为 hardened
+func (k hdKey) child(index uint32) (hdKey, error) {
+	var data []byte
+	if index >= 0x80000000 {
+		data = append([]byte{0}, k.key...)
+	} else {
+		priv, err := crypto.ToECDSA(k.key)
+		if err != nil {
+			return hdKey{}, err
+		}
+		data = crypto.CompressPubkey(&priv.PublicKey)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, k.chain)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(crypto.S256().Params().N) >= 0 {
+		return hdKey{}, fmt.Errorf("invalid child key at index %d", index)
+	}
+	child := il.Add(il, new(big.Int).SetBytes(k.key))
+	child.Mod(child, crypto.S256().Params().N)
+	if child.Sign() == 0 {
+		return hdKey{}, fmt.Errorf("invalid child key at index %d", index)
+	}
+	return hdKey{key: common.LeftPadBytes(child.Bytes(), 32), chain: sum[32:]}, nil
+}
+
+func validHDKey(key []byte) bool {
+	k := new(big.Int).SetBytes(key)
+	return k.Sign() > 0 && k.Cmp(crypto.S256().Params().N) < 0
+}
+
+func normalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(mnemonic), " ")
+}
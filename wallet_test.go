@@ -0,0 +1,123 @@
+package laukit
+
+import (
+	"encoding/hex"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"strings"
+	"testing"
+)
+
+// BIP-39 参考实现 (trezor/python-mnemonic) 的测试向量, 密码为 TREZOR
+// 助记词生成的钱包与直接用种子生成的钱包派生出相同的私钥
+func TestWalletBIP39(t *testing.T) {
+	for _, v := range []struct{ mnemonic, seed string }{
+		{
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			"legal winner thank year wave sausage worth useful legal winner thank yellow",
+			"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+	} {
+		if !ValidateMnemonic(v.mnemonic) {
+			t.Fatalf("%q should be valid", v.mnemonic)
+		}
+		w, err := NewWallet(v.mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.Mnemonic() != v.mnemonic {
+			t.Fatalf("unexpected mnemonic %q", w.Mnemonic())
+		}
+		expected, err := NewWalletFromSeed(MustDecodeString(v.seed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for index := uint32(0); index < 3; index++ {
+			key, err := w.PrivateKey(index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := expected.PrivateKey(index)
+			if hex.EncodeToString(crypto.FromECDSA(key)) != hex.EncodeToString(crypto.FromECDSA(want)) {
+				t.Fatalf("%q: key %d does not match seed", v.mnemonic, index)
+			}
+		}
+		// 密码不同种子不同
+		other, _ := NewWallet(v.mnemonic, "")
+		got, _ := other.Address(0)
+		if want, _ := expected.Address(0); got == want {
+			t.Fatalf("%q: passphrase should change derived keys", v.mnemonic)
+		}
+	}
+
+	mnemonic, err := NewMnemonic(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(strings.Fields(mnemonic)) != 24 || !ValidateMnemonic(mnemonic) {
+		t.Fatalf("unexpected mnemonic %q", mnemonic)
+	}
+	// 校验和错误
+	if ValidateMnemonic(strings.Repeat("abandon ", 12)) {
+		t.Fatal("expected invalid checksum")
+	}
+	if _, err := NewWallet("abandon abandon", ""); err == nil {
+		t.Fatal("expected invalid mnemonic error")
+	}
+}
+
+// BIP-32 测试向量 1
+func TestWalletBIP32(t *testing.T) {
+	w, err := NewWalletFromSeed(MustDecodeString("000102030405060708090a0b0c0d0e0f"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(w.master.key) != "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35" ||
+		hex.EncodeToString(w.master.chain) != "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508" {
+		t.Fatalf("unexpected master key %x %x", w.master.key, w.master.chain)
+	}
+	for path, expected := range map[string]string{
+		"m/0'":                   "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea",
+		"m/0'/1":                 "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368",
+		"m/0'/1/2'":              "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca",
+		"m/0'/1/2'/2":            "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4",
+		"m/0'/1/2'/2/1000000000": "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8",
+	} {
+		key, err := w.Derive(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(crypto.FromECDSA(key)) != expected {
+			t.Fatalf("%s: unexpected key %x", path, crypto.FromECDSA(key))
+		}
+	}
+}
+
+func TestWalletAddresses(t *testing.T) {
+	w, err := NewWallet("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addresses, err := w.Addresses(0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addresses) != 3 || addresses[0] != common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94") {
+		t.Fatalf("unexpected addresses %v", addresses)
+	}
+	auth, err := w.Eauth(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crypto.PubkeyToAddress(auth.Private.PublicKey) != addresses[2] {
+		t.Fatal("eauth key does not match derived address")
+	}
+	// 自定义路径前缀
+	w.BasePath = "m/44'/60'/1'/0"
+	if addr, _ := w.Address(0); addr == addresses[0] {
+		t.Fatal("base path should change derived address")
+	}
+}